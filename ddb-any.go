@@ -0,0 +1,92 @@
+package ddbmarshal
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+	"strconv"
+)
+
+var interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+
+// SetUseNumber makes numbers decoded into interface{} to be Number instead of float64, keeping their exact text
+func (marshaller *DdbMarshaller) SetUseNumber(value bool) {
+	marshaller.useNumber = value
+}
+
+// UnmarshalAny converts the item to generic go values:
+// S to string, N to float64 (or Number, see SetUseNumber), B to []byte, BOOL to bool,
+// SS to []string, NS to []float64 (or []Number), BS to [][]byte,
+// M to map[string]interface{}, L to []interface{} and NULL to nil
+func (me *DdbMarshaller) UnmarshalAny(source map[string]*dynamodb.AttributeValue) (map[string]interface{}, error) {
+	return me.attributeToInterfaceMap(source)
+}
+
+// MarshalAny builds the item from generic go values, these may be of any supported type;
+// nil values are stored as NULL (including the nested ones) regardless of SetMarshalNilAsNull, reversing UnmarshalAny
+func (me *DdbMarshaller) MarshalAny(source map[string]interface{}) (map[string]*dynamodb.AttributeValue, error) {
+	return me.ddbMarshalMap(reflect.ValueOf(source), specs{nullable: true})
+}
+
+// attributeToInterface converts the attribute to the generic go value, same way as UnmarshalAny does
+func (me *DdbMarshaller) attributeToInterface(attr *dynamodb.AttributeValue) (interface{}, error) {
+	switch {
+	case attr == nil || aws.BoolValue(attr.NULL):
+		return nil, nil
+	case attr.S != nil:
+		return *attr.S, nil
+	case attr.N != nil:
+		if me.useNumber {
+			return Number(*attr.N), nil
+		}
+		val, err := strconv.ParseFloat(*attr.N, 64)
+		return val, invalidValue(err)
+	case attr.BOOL != nil:
+		return *attr.BOOL, nil
+	case attr.B != nil:
+		return attr.B, nil
+	case attr.SS != nil:
+		return aws.StringValueSlice(attr.SS), nil
+	case attr.NS != nil:
+		if me.useNumber {
+			result := make([]Number, len(attr.NS))
+			for i, str := range attr.NS {
+				result[i] = Number(aws.StringValue(str))
+			}
+			return result, nil
+		}
+		var result []float64
+		err := setValueWithParsedNumbers(reflect.ValueOf(&result).Elem(), attr.NS)
+		return result, err
+	case attr.BS != nil:
+		return attr.BS, nil
+	case attr.M != nil:
+		return me.attributeToInterfaceMap(attr.M)
+	case attr.L != nil:
+		result := make([]interface{}, len(attr.L))
+		for i, v := range attr.L {
+			if val, err := me.attributeToInterface(v); err != nil {
+				index := fmt.Sprintf("[%d]", i)
+				return nil, withPath(err, index, index)
+			} else {
+				result[i] = val
+			}
+		}
+		return result, nil
+	default:
+		return nil, typeMismatch(interfaceType, attr, "any")
+	}
+}
+
+func (me *DdbMarshaller) attributeToInterfaceMap(attrs map[string]*dynamodb.AttributeValue) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(attrs))
+	for k, v := range attrs {
+		if val, err := me.attributeToInterface(v); err != nil {
+			return nil, withPath(err, "["+k+"]", k)
+		} else {
+			result[k] = val
+		}
+	}
+	return result, nil
+}
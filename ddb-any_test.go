@@ -0,0 +1,137 @@
+package ddbmarshal
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+	"testing"
+)
+
+func prepareAnyDdb() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"s":    {S: aws.String("str")},
+		"n":    {N: aws.String("1.5")},
+		"b":    {B: []byte("bin")},
+		"bool": {BOOL: aws.Bool(true)},
+		"ss":   {SS: aws.StringSlice([]string{"a", "b"})},
+		"ns":   {NS: aws.StringSlice([]string{"1", "2"})},
+		"bs":   {BS: [][]byte{[]byte("x")}},
+		"null": {NULL: aws.Bool(true)},
+		"m":    {M: map[string]*dynamodb.AttributeValue{"k": {S: aws.String("v")}}},
+		"l":    {L: []*dynamodb.AttributeValue{{N: aws.String("7")}, {S: aws.String("x")}}},
+	}
+}
+
+func TestDdbMarshaller_UnmarshalAny(t *testing.T) {
+	tests := []struct {
+		name      string
+		useNumber bool
+		want      map[string]interface{}
+	}{
+		{
+			name:      "float numbers",
+			useNumber: false,
+			want: map[string]interface{}{
+				"s":    "str",
+				"n":    1.5,
+				"b":    []byte("bin"),
+				"bool": true,
+				"ss":   []string{"a", "b"},
+				"ns":   []float64{1, 2},
+				"bs":   [][]byte{[]byte("x")},
+				"null": nil,
+				"m":    map[string]interface{}{"k": "v"},
+				"l":    []interface{}{7.0, "x"},
+			},
+		},
+		{
+			name:      "exact numbers",
+			useNumber: true,
+			want: map[string]interface{}{
+				"s":    "str",
+				"n":    Number("1.5"),
+				"b":    []byte("bin"),
+				"bool": true,
+				"ss":   []string{"a", "b"},
+				"ns":   []Number{"1", "2"},
+				"bs":   [][]byte{[]byte("x")},
+				"null": nil,
+				"m":    map[string]interface{}{"k": "v"},
+				"l":    []interface{}{Number("7"), "x"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			me := NewMarshaller()
+			me.SetUseNumber(tt.useNumber)
+			got, err := me.UnmarshalAny(prepareAnyDdb())
+			if err != nil {
+				t.Fatalf("UnmarshalAny() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmarshalAny() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDdbMarshaller_MarshalAny(t *testing.T) {
+	me := NewMarshaller()
+	me.SetUseNumber(true)
+	me.SetMarshalNilAsNull(true)
+	data, err := me.UnmarshalAny(prepareAnyDdb())
+	if err != nil {
+		t.Fatalf("UnmarshalAny() error = %v", err)
+	}
+	got, err := me.MarshalAny(data)
+	if err != nil {
+		t.Fatalf("MarshalAny() error = %v", err)
+	}
+	if want := prepareAnyDdb(); !reflect.DeepEqual(got, want) {
+		t.Errorf("MarshalAny() got = %v, want %v", got, want)
+	}
+}
+
+func TestDdbMarshaller_MarshalAnyNulls(t *testing.T) {
+	me := NewMarshaller()
+	source := map[string]*dynamodb.AttributeValue{
+		"a": {NULL: aws.Bool(true)},
+		"m": {M: map[string]*dynamodb.AttributeValue{"k": {NULL: aws.Bool(true)}}},
+		"l": {L: []*dynamodb.AttributeValue{{NULL: aws.Bool(true)}}},
+	}
+	data, err := me.UnmarshalAny(source)
+	if err != nil {
+		t.Fatalf("UnmarshalAny() error = %v", err)
+	}
+	if want := (map[string]interface{}{"a": nil, "m": map[string]interface{}{"k": nil}, "l": []interface{}{nil}}); !reflect.DeepEqual(data, want) {
+		t.Errorf("UnmarshalAny() got = %v, want %v", data, want)
+	}
+	got, err := me.MarshalAny(data)
+	if err != nil {
+		t.Fatalf("MarshalAny() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, source) {
+		t.Errorf("MarshalAny() got = %v, want %v", got, source)
+	}
+}
+
+func TestDdbMarshaller_UnmarshalInterfaceFields(t *testing.T) {
+	var got struct {
+		Value interface{}            `ddb:"value"`
+		Attrs map[string]interface{} `ddb:"attrs"`
+	}
+	err := NewMarshaller().Unmarshal(&got, map[string]*dynamodb.AttributeValue{
+		"value": {L: []*dynamodb.AttributeValue{{BOOL: aws.Bool(false)}}},
+		"attrs": {M: map[string]*dynamodb.AttributeValue{"n": {N: aws.String("3")}}},
+	})
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if want := []interface{}{false}; !reflect.DeepEqual(got.Value, want) {
+		t.Errorf("Unmarshal() got = %v, want %v", got.Value, want)
+	}
+	if want := map[string]interface{}{"n": 3.0}; !reflect.DeepEqual(got.Attrs, want) {
+		t.Errorf("Unmarshal() got = %v, want %v", got.Attrs, want)
+	}
+}
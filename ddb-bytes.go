@@ -0,0 +1,77 @@
+package ddbmarshal
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+	"strings"
+)
+
+const uuidLength = 16
+
+// bytesOf returns content of the byte slice or the byte array
+func bytesOf(value reflect.Value) []byte {
+	if value.Kind() == reflect.Slice {
+		return value.Bytes()
+	}
+	result := make([]byte, value.Len())
+	reflect.Copy(reflect.ValueOf(result), value)
+	return result
+}
+
+// marshalBytes stores bytes as B, or as S of hex digits with "hex" tag option,
+// or as S of canonical UUID form (8-4-4-4-12 hex digits) with "uuid" tag option; nil slice is not stored
+func marshalBytes(value reflect.Value, spec specs) (*dynamodb.AttributeValue, error) {
+	if value.Kind() == reflect.Slice && value.IsNil() {
+		return nil, nil
+	}
+	bytes := bytesOf(value)
+	switch {
+	case spec.asUuid:
+		if len(bytes) != uuidLength {
+			return nil, errors.New(fmt.Sprintf("can't store %d bytes of %v as UUID", len(bytes), value.Type()))
+		}
+		str := hex.EncodeToString(bytes)
+		str = str[0:8] + "-" + str[8:12] + "-" + str[12:16] + "-" + str[16:20] + "-" + str[20:]
+		return &dynamodb.AttributeValue{S: aws.String(str)}, nil
+	case spec.asHex:
+		return &dynamodb.AttributeValue{S: aws.String(hex.EncodeToString(bytes))}, nil
+	default:
+		return &dynamodb.AttributeValue{B: bytes}, nil
+	}
+}
+
+// unmarshalBytes reads B, or S of hex digits (dashes are ignored, so UUID form is accepted);
+// length of the byte array is validated
+func unmarshalBytes(value reflect.Value, attr *dynamodb.AttributeValue) error {
+	bytes := attr.B
+	if attr.S != nil {
+		var err error
+		if bytes, err = hex.DecodeString(strings.ReplaceAll(*attr.S, "-", "")); err != nil {
+			return invalidValue(err)
+		}
+	} else if attr.B == nil {
+		return typeMismatch(value.Type(), attr, "B or S")
+	}
+	if value.Kind() == reflect.Slice {
+		value.SetBytes(bytes)
+		return nil
+	}
+	if len(bytes) != value.Len() {
+		return invalidValue(errors.New(fmt.Sprintf("%d bytes can't be stored in %v", len(bytes), value.Type())))
+	}
+	reflect.Copy(value, reflect.ValueOf(bytes))
+	return nil
+}
+
+// setArrayFromSlice copies decoded elements into the fixed-size array, the number of elements is validated
+func setArrayFromSlice(value reflect.Value, slice reflect.Value) error {
+	if slice.Len() != value.Len() {
+		return invalidValue(errors.New(fmt.Sprintf("%d elements can't be stored in %v", slice.Len(), value.Type())))
+	}
+	reflect.Copy(value, slice)
+	return nil
+}
@@ -0,0 +1,119 @@
+package ddbmarshal
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+	"testing"
+)
+
+type testArrays struct {
+	Id      [16]byte        `ddb:"id"`
+	Key     [16]byte        `ddb:"key,uuid"`
+	Digest  [4]byte         `ddb:"digest,hex"`
+	Raw     []byte          `ddb:"raw,hex"`
+	Digests [][4]byte       `ddb:"digests"`
+	Point   [3]int          `ddb:"point,list"`
+	Pair    [2]string       `ddb:"pair"`
+	Items   [1]testLineItem `ddb:"items"`
+}
+
+var testUuid = [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+
+func prepareArraysStruct() *testArrays {
+	return &testArrays{
+		Id:      testUuid,
+		Key:     testUuid,
+		Digest:  [4]byte{0xde, 0xad, 0xbe, 0xef},
+		Raw:     []byte{1, 2},
+		Digests: [][4]byte{{1, 2, 3, 4}},
+		Point:   [3]int{1, 1, 2},
+		Pair:    [2]string{"a", "b"},
+		Items:   [1]testLineItem{{Sku: "x", Qty: 1}},
+	}
+}
+
+func prepareArraysDdb() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"id":      {B: testUuid[:]},
+		"key":     {S: aws.String("123e4567-e89b-12d3-a456-426614174000")},
+		"digest":  {S: aws.String("deadbeef")},
+		"raw":     {S: aws.String("0102")},
+		"digests": {BS: [][]byte{{1, 2, 3, 4}}},
+		"point":   {L: []*dynamodb.AttributeValue{{N: aws.String("1")}, {N: aws.String("1")}, {N: aws.String("2")}}},
+		"pair":    {SS: aws.StringSlice([]string{"a", "b"})},
+		"items": {L: []*dynamodb.AttributeValue{{M: map[string]*dynamodb.AttributeValue{
+			"sku": {S: aws.String("x")},
+			"qty": {N: aws.String("1")},
+		}}}},
+	}
+}
+
+func TestDdbMarshaller_Arrays(t *testing.T) {
+	me := NewMarshaller()
+	got, err := me.Marshal(prepareArraysStruct())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := prepareArraysDdb(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Marshal() got = %v, want %v", got, want)
+	}
+	var data testArrays
+	if err := me.Unmarshal(&data, got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if want := prepareArraysStruct(); !reflect.DeepEqual(&data, want) {
+		t.Errorf("Unmarshal() got = %v, want %v", data, want)
+	}
+}
+
+func TestDdbMarshaller_UnmarshalArrayLength(t *testing.T) {
+	tests := []struct {
+		name   string
+		source map[string]*dynamodb.AttributeValue
+	}{
+		{"short binary", map[string]*dynamodb.AttributeValue{"id": {B: []byte{1, 2}}}},
+		{"long uuid", map[string]*dynamodb.AttributeValue{"key": {S: aws.String("123e4567-e89b-12d3-a456-42661417400000")}}},
+		{"not hex", map[string]*dynamodb.AttributeValue{"digest": {S: aws.String("xyz")}}},
+		{"too many elements", map[string]*dynamodb.AttributeValue{"pair": {SS: aws.StringSlice([]string{"a", "b", "c"})}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var data testArrays
+			if err := NewMarshaller().Unmarshal(&data, tt.source); err == nil {
+				t.Errorf("Unmarshal() error expected")
+			}
+		})
+	}
+}
+
+func TestDdbMarshaller_MarshalUuidLength(t *testing.T) {
+	source := &struct {
+		Short [8]byte `ddb:"short,uuid"`
+	}{}
+	if _, err := NewMarshaller().Marshal(source); err == nil {
+		t.Errorf("Marshal() error expected")
+	}
+}
+
+func TestDdbMarshaller_NilBytes(t *testing.T) {
+	source := &struct {
+		Data     []byte            `ddb:"data"`
+		Hex      []byte            `ddb:"hex,hex"`
+		Nullable []byte            `ddb:"nullable,nullable"`
+		ByKey    map[string][]byte `ddb:"byKey"`
+		List     [][]byte          `ddb:"list,list"`
+	}{ByKey: map[string][]byte{"x": nil}, List: [][]byte{nil}}
+	got, err := NewMarshaller().Marshal(source)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := map[string]*dynamodb.AttributeValue{
+		"nullable": {NULL: aws.Bool(true)},
+		"byKey":    {M: map[string]*dynamodb.AttributeValue{}},
+		"list":     {L: []*dynamodb.AttributeValue{{NULL: aws.Bool(true)}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Marshal() got = %v, want %v", got, want)
+	}
+}
@@ -1,21 +1,53 @@
 package ddbmarshal
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
 	"strings"
+	"sync"
 )
 
 const (
-	TagDdb          = "ddb"
-	TagItemHashJey  = "hash-key"
-	TagItemRangeKey = "range-key"
-	TagItemRequired = "required"
-	TagItemTtlField = "ttl-ts"
+	TagDdb           = "ddb"
+	TagItemHashJey   = "hash-key"
+	TagItemRangeKey  = "range-key"
+	TagItemRequired  = "required"
+	TagItemTtlField  = "ttl-ts"
+	TagItemOmitEmpty = "omitempty"
+	TagItemText      = "text"
+	TagItemBinary    = "binary"
+	TagItemLayout    = "layout"
+	TagItemDefault   = "default"
+	TagItemSet       = "set"
+	TagItemList      = "list"
+	TagItemNullable  = "nullable"
+	TagItemHex       = "hex"
+	TagItemUuid      = "uuid"
+	TagItemInline    = "inline"
+	TagItemRemain    = "remain"
+	TagItemMin       = "min"
+	TagItemMax       = "max"
+	TagItemLen       = "len"
+	TagItemPattern   = "pattern"
+	TagItemOneOf     = "oneof"
+	TagItemNonEmpty  = "nonempty"
 )
 
 type DdbMarshaller struct {
 	marshalAllPublicFields     bool
 	decapitalizeUntaggedFields bool
 	addPrefixToTheFieldNames   string
+	marshalNilAsNull           bool
+	converters                 map[reflect.Type]converter
+	timeFormat                 string
+	useNumber                  bool
+	encodeSlicesAsLists        bool
+	fieldCache                 sync.Map // fieldCacheKey -> []structField
+	strictUnmarshal            bool
+	marshalDefaults            bool
+	rulesCache                 sync.Map // reflect.Type -> bool, see hasRules
+	namingStrategy             NamingStrategy
 	// TODO: options:
 	//  - should we marshal fields without tags?
 	//    - add ighore flag then
@@ -33,14 +65,23 @@ func NewMarshaller() *DdbMarshaller {
 
 func (marshaller *DdbMarshaller) SetMarshalAllPublicFields(value bool) {
 	marshaller.marshalAllPublicFields = value
+	marshaller.resetFieldCache()
 }
 
 func (marshaller *DdbMarshaller) SetDecapitalizeUntaggedFieldNames(value bool) {
 	marshaller.decapitalizeUntaggedFields = value
+	marshaller.resetFieldCache()
 }
 
 func (marshaller *DdbMarshaller) SetFieldNamePrefix(prefix string) {
 	marshaller.addPrefixToTheFieldNames = prefix
+	marshaller.resetFieldCache()
+}
+
+// SetMarshalNilAsNull makes nil pointers stored as NULL attribute instead of omitting the attribute,
+// same as "nullable" tag option does for a single field
+func (marshaller *DdbMarshaller) SetMarshalNilAsNull(value bool) {
+	marshaller.marshalNilAsNull = value
 }
 
 type specs struct {
@@ -49,15 +90,72 @@ type specs struct {
 	isHashKey  bool
 	isRangeKey bool
 	isTtlField bool
+	omitEmpty  bool
+	asText     bool
+	asBinary   bool
+	timeFormat string
+	asSet      bool
+	asList     bool
+	nullable   bool
+	asHex      bool
+	asUuid     bool
+	inline     bool
+	remain     bool
+	// defaultValue is the literal of "default=" option, validated against the field type on the first use
+	defaultValue string
+	hasDefault   bool
+	rules        *validationRules
 }
 
+// ParseDdbTag parses the comma-separated tag items, the value of "key=value" item may be quoted with single quotes
+// to contain commas, i.e. `ddb:"at,layout='Mon, 02 Jan 2006'"`
 func ParseDdbTag(tag string) (specs, error) {
-	items := strings.Split(tag, ",")
+	items, err := splitTagItems(tag)
+	if err != nil {
+		return specs{}, err
+	}
 	result := specs{
 		name: strings.TrimSpace(items[0]),
 	}
+	// cutKey is the key of the previous unquoted item which value can't contain commas
+	cutKey := ""
 	for _, v := range items[1:] {
-		switch strings.TrimSpace(v) {
+		item := strings.TrimSpace(v)
+		if key, value, ok := strings.Cut(item, "="); ok {
+			key = strings.TrimSpace(key)
+			value, quoted := unquoteTagValue(value)
+			cutKey = ""
+			if !quoted && (key == TagItemLayout || key == TagItemPattern) {
+				cutKey = key
+			}
+			switch key {
+			case TagItemLayout:
+				if err := validateTimeLayout(value); err != nil {
+					return result, err
+				}
+				result.timeFormat = value
+			case TagItemDefault:
+				result.defaultValue = value
+				result.hasDefault = true
+			default:
+				rules := result.rules
+				if rules == nil {
+					rules = &validationRules{}
+				}
+				if ok, err := rules.parseRule(key, value); err != nil {
+					return result, err
+				} else if ok {
+					result.rules = rules
+				}
+			}
+			continue
+		}
+		if isTimeFormat(item) {
+			result.timeFormat = item
+			cutKey = ""
+			continue
+		}
+		switch item {
 		case TagItemRequired:
 			result.required = true
 		case TagItemHashJey:
@@ -66,11 +164,71 @@ func ParseDdbTag(tag string) (specs, error) {
 			result.isRangeKey = true
 		case TagItemTtlField:
 			result.isTtlField = true
+		case TagItemOmitEmpty:
+			result.omitEmpty = true
+		case TagItemText:
+			result.asText = true
+		case TagItemBinary:
+			result.asBinary = true
+		case TagItemSet:
+			result.asSet = true
+		case TagItemList:
+			result.asList = true
+		case TagItemNullable:
+			result.nullable = true
+		case TagItemHex:
+			result.asHex = true
+		case TagItemUuid:
+			result.asUuid = true
+		case TagItemInline:
+			result.inline = true
+		case TagItemRemain:
+			result.remain = true
+		case TagItemNonEmpty:
+			if result.rules == nil {
+				result.rules = &validationRules{}
+			}
+			result.rules.nonEmpty = true
+		default:
+			if cutKey != "" {
+				return result, errors.New(fmt.Sprintf("unknown tag item %q after %q option, quote the value containing commas: %s='...'", item, cutKey, cutKey))
+			}
 		}
+		cutKey = ""
 	}
 	return result, nil
 }
 
+// splitTagItems splits the tag by commas except ones in the quoted values, the quote starts right after "="
+// and ends before the comma or the end of the tag
+func splitTagItems(tag string) ([]string, error) {
+	var items []string
+	start, quoted := 0, false
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case quoted:
+			quoted = !(tag[i] == '\'' && (i+1 == len(tag) || tag[i+1] == ','))
+		case tag[i] == '\'' && i > 0 && tag[i-1] == '=':
+			quoted = true
+		case tag[i] == ',':
+			items = append(items, tag[start:i])
+			start = i + 1
+		}
+	}
+	if quoted {
+		return nil, errors.New(fmt.Sprintf("unterminated quoted value in tag %q", tag))
+	}
+	return append(items, tag[start:]), nil
+}
+
+// unquoteTagValue strips the single quotes around the value, reporting whether the value was quoted
+func unquoteTagValue(value string) (string, bool) {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1], true
+	}
+	return value, false
+}
+
 func (s specs) IsRequired() bool {
 	return s.required
 }
@@ -91,6 +249,10 @@ func (s specs) IsTtlField() bool {
 	return s.isTtlField
 }
 
+func (s specs) IsOmitEmpty() bool {
+	return s.omitEmpty
+}
+
 func (s specs) FieldName() string {
 	return s.name
 }
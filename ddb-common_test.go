@@ -2,6 +2,7 @@ package ddbmarshal
 
 import (
 	"reflect"
+	"regexp"
 	"testing"
 )
 
@@ -85,6 +86,167 @@ func Test_parseSpecs(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"name, omitempty",
+			args{
+				"myColumn,omitempty",
+			},
+			specs{
+				name:      "myColumn",
+				omitEmpty: true,
+			},
+			false,
+		},
+		{
+			"name, text, binary",
+			args{
+				"myColumn,text,binary",
+			},
+			specs{
+				name:     "myColumn",
+				asText:   true,
+				asBinary: true,
+			},
+			false,
+		},
+		{
+			"name, time format",
+			args{
+				"myColumn,rfc3339",
+			},
+			specs{
+				name:       "myColumn",
+				timeFormat: TimeFormatRFC3339,
+			},
+			false,
+		},
+		{
+			"name, time layout",
+			args{
+				"myColumn,layout=2006-01-02 15:04",
+			},
+			specs{
+				name:       "myColumn",
+				timeFormat: "2006-01-02 15:04",
+			},
+			false,
+		},
+		{
+			"quoted time layout with commas",
+			args{
+				"myColumn,layout='Mon, 02 Jan 2006',omitempty",
+			},
+			specs{
+				name:       "myColumn",
+				timeFormat: "Mon, 02 Jan 2006",
+				omitEmpty:  true,
+			},
+			false,
+		},
+		{
+			"unquoted time layout with commas",
+			args{
+				"myColumn,layout=Mon, 02 Jan 2006",
+			},
+			specs{
+				name:       "myColumn",
+				timeFormat: "Mon",
+			},
+			true,
+		},
+		{
+			"unterminated quoted value",
+			args{
+				"myColumn,layout='Mon, 02 Jan 2006",
+			},
+			specs{},
+			true,
+		},
+		{
+			"empty time layout",
+			args{
+				"myColumn,layout=",
+			},
+			specs{
+				name: "myColumn",
+			},
+			true,
+		},
+		{
+			"name, default set",
+			args{
+				"myColumn,default=a|b,omitempty",
+			},
+			specs{
+				name:         "myColumn",
+				defaultValue: "a|b",
+				hasDefault:   true,
+				omitEmpty:    true,
+			},
+			false,
+		},
+		{
+			"name, validation rules",
+			args{
+				"myColumn,min=1,max=10,len=2,oneof=a|b,nonempty",
+			},
+			specs{
+				name: "myColumn",
+				rules: &validationRules{
+					min:      func() *float64 { v := 1.0; return &v }(),
+					max:      func() *float64 { v := 10.0; return &v }(),
+					length:   func() *int { v := 2; return &v }(),
+					oneOf:    []string{"a", "b"},
+					nonEmpty: true,
+				},
+			},
+			false,
+		},
+		{
+			"invalid min",
+			args{
+				"myColumn,min=one",
+			},
+			specs{
+				name: "myColumn",
+			},
+			true,
+		},
+		{
+			"quoted pattern with commas",
+			args{
+				"myColumn,pattern='^[a-z]{1,3}$',nonempty",
+			},
+			specs{
+				name: "myColumn",
+				rules: &validationRules{
+					pattern:  regexp.MustCompile("^[a-z]{1,3}$"),
+					nonEmpty: true,
+				},
+			},
+			false,
+		},
+		{
+			"unquoted pattern with commas",
+			args{
+				"myColumn,pattern=^[a-z]{1,3}$",
+			},
+			specs{
+				name:  "myColumn",
+				rules: &validationRules{pattern: regexp.MustCompile("^[a-z]{1")},
+			},
+			true,
+		},
+		{
+			"invalid pattern",
+			args{
+				"myColumn,pattern=[a-",
+			},
+			specs{
+				name: "myColumn",
+			},
+			true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,71 @@
+package ddbmarshal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+)
+
+// EncodeFunc converts the value of the registered type to the attribute, nil attribute means the value is not stored
+type EncodeFunc func(value interface{}) (*dynamodb.AttributeValue, error)
+
+// DecodeFunc converts the attribute to the value of the registered type
+type DecodeFunc func(attr *dynamodb.AttributeValue) (interface{}, error)
+
+type converter struct {
+	encode EncodeFunc
+	decode DecodeFunc
+}
+
+// RegisterConverter teaches the marshaller to handle the type it can't add methods to, i.e. third-party types.
+// Converters take precedence over any other handling of the type and apply at any depth: fields, map values,
+// list elements and nested structs. Either function may be nil to convert only one direction.
+// Converters are expected to be registered before the marshaller is used.
+func (marshaller *DdbMarshaller) RegisterConverter(typ reflect.Type, encode EncodeFunc, decode DecodeFunc) {
+	if marshaller.converters == nil {
+		marshaller.converters = make(map[reflect.Type]converter)
+	}
+	marshaller.converters[typ] = converter{encode: encode, decode: decode}
+}
+
+// marshalConverted reports false if there is no encoder registered for the type of the value
+func (me *DdbMarshaller) marshalConverted(value reflect.Value) (*dynamodb.AttributeValue, bool, error) {
+	if len(me.converters) == 0 {
+		return nil, false, nil
+	}
+	if (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) && value.IsNil() {
+		return nil, false, nil
+	}
+	if conv, ok := me.converters[value.Type()]; ok && conv.encode != nil {
+		attr, err := conv.encode(value.Interface())
+		return attr, true, err
+	}
+	return nil, false, nil
+}
+
+// unmarshalConverted reports false if there is no decoder registered for the type of the value
+func (me *DdbMarshaller) unmarshalConverted(value reflect.Value, attr *dynamodb.AttributeValue) (bool, error) {
+	conv, ok := me.converters[value.Type()]
+	if !ok || conv.decode == nil {
+		return false, nil
+	}
+	decoded, err := conv.decode(attr)
+	if err != nil {
+		return true, err
+	}
+	if decoded == nil {
+		value.Set(reflect.Zero(value.Type()))
+		return true, nil
+	}
+	result := reflect.ValueOf(decoded)
+	switch {
+	case result.Type().AssignableTo(value.Type()):
+		value.Set(result)
+	case result.Type().ConvertibleTo(value.Type()):
+		value.Set(result.Convert(value.Type()))
+	default:
+		return true, errors.New(fmt.Sprintf("converter for %v returned %v", value.Type(), result.Type()))
+	}
+	return true, nil
+}
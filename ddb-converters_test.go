@@ -0,0 +1,94 @@
+package ddbmarshal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+	"testing"
+)
+
+// testDecimal stands for a third-party type with no ddb support
+type testDecimal struct {
+	unscaled int64
+	scale    int
+}
+
+func (d testDecimal) String() string {
+	return fmt.Sprintf("%de-%d", d.unscaled, d.scale)
+}
+
+func encodeTestDecimal(value interface{}) (*dynamodb.AttributeValue, error) {
+	return &dynamodb.AttributeValue{N: aws.String(value.(testDecimal).String())}, nil
+}
+
+func decodeTestDecimal(attr *dynamodb.AttributeValue) (interface{}, error) {
+	if attr.N == nil {
+		return nil, errors.New("number is expected")
+	}
+	var d testDecimal
+	_, err := fmt.Sscanf(*attr.N, "%de-%d", &d.unscaled, &d.scale)
+	return d, err
+}
+
+type testConverted struct {
+	Amount  testDecimal            `ddb:"amount"`
+	Maybe   *testDecimal           `ddb:"maybe"`
+	ByName  map[string]testDecimal `ddb:"byName"`
+	History []testDecimal          `ddb:"history"`
+	Nested  struct {
+		Amount testDecimal `ddb:"amount"`
+	} `ddb:"nested"`
+}
+
+func prepareConvertedStruct() *testConverted {
+	result := &testConverted{
+		Amount:  testDecimal{12345, 2},
+		Maybe:   &testDecimal{1, 1},
+		ByName:  map[string]testDecimal{"x": {5, 0}},
+		History: []testDecimal{{1, 0}, {1, 0}},
+	}
+	result.Nested.Amount = testDecimal{7, 3}
+	return result
+}
+
+func prepareConvertedDdb() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"amount":  {N: aws.String("12345e-2")},
+		"maybe":   {N: aws.String("1e-1")},
+		"byName":  {M: map[string]*dynamodb.AttributeValue{"x": {N: aws.String("5e-0")}}},
+		"history": {L: []*dynamodb.AttributeValue{{N: aws.String("1e-0")}, {N: aws.String("1e-0")}}},
+		"nested":  {M: map[string]*dynamodb.AttributeValue{"amount": {N: aws.String("7e-3")}}},
+	}
+}
+
+func TestDdbMarshaller_RegisterConverter(t *testing.T) {
+	me := NewMarshaller()
+	me.RegisterConverter(reflect.TypeOf(testDecimal{}), encodeTestDecimal, decodeTestDecimal)
+	got, err := me.Marshal(prepareConvertedStruct())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := prepareConvertedDdb(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Marshal() got = %v, want %v", got, want)
+	}
+	var data testConverted
+	if err := me.Unmarshal(&data, got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if want := prepareConvertedStruct(); !reflect.DeepEqual(&data, want) {
+		t.Errorf("Unmarshal() got = %v, want %v", data, want)
+	}
+}
+
+func TestDdbMarshaller_RegisterConverterWrongType(t *testing.T) {
+	me := NewMarshaller()
+	me.RegisterConverter(reflect.TypeOf(testDecimal{}), encodeTestDecimal, func(*dynamodb.AttributeValue) (interface{}, error) {
+		return "not a decimal", nil
+	})
+	var data testConverted
+	if err := me.Unmarshal(&data, prepareConvertedDdb()); err == nil {
+		t.Errorf("Unmarshal() is expected to fail on wrong converter result")
+	}
+}
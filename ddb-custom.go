@@ -0,0 +1,108 @@
+package ddbmarshal
+
+import (
+	"encoding"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+)
+
+// DdbAttributeMarshaler is implemented by types providing their own attribute representation,
+// returning nil attribute means the value is not stored
+type DdbAttributeMarshaler interface {
+	MarshalDdb() (*dynamodb.AttributeValue, error)
+}
+
+// DdbAttributeUnmarshaler is implemented by types reading themselves from the attribute,
+// it is expected to be implemented with pointer receiver
+type DdbAttributeUnmarshaler interface {
+	UnmarshalDdb(*dynamodb.AttributeValue) error
+}
+
+var (
+	attributeMarshalerType   = reflect.TypeOf((*DdbAttributeMarshaler)(nil)).Elem()
+	attributeUnmarshalerType = reflect.TypeOf((*DdbAttributeUnmarshaler)(nil)).Elem()
+	textMarshalerType        = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType      = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	binaryMarshalerType      = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	binaryUnmarshalerType    = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+)
+
+// marshalCustom reports false if the value does not implement DdbAttributeMarshaler with either value or pointer receiver
+func marshalCustom(value reflect.Value) (*dynamodb.AttributeValue, bool, error) {
+	if marshaler, ok := asInterface(value, attributeMarshalerType).(DdbAttributeMarshaler); ok {
+		attr, err := marshaler.MarshalDdb()
+		return attr, true, err
+	}
+	return nil, false, nil
+}
+
+// unmarshalCustom reports false if the value does not implement DdbAttributeUnmarshaler
+func unmarshalCustom(value reflect.Value, attr *dynamodb.AttributeValue) (bool, error) {
+	if unmarshaler, ok := asInterface(value, attributeUnmarshalerType).(DdbAttributeUnmarshaler); ok {
+		return true, unmarshaler.UnmarshalDdb(attr)
+	}
+	return false, nil
+}
+
+// marshalTextOrBinary stores encoding.TextMarshaler as S and encoding.BinaryMarshaler as B,
+// text is preferred when both are implemented unless "binary" tag option is used
+func marshalTextOrBinary(value reflect.Value, spec specs) (*dynamodb.AttributeValue, bool, error) {
+	marshalText := func() (*dynamodb.AttributeValue, bool, error) {
+		if marshaler, ok := asInterface(value, textMarshalerType).(encoding.TextMarshaler); ok {
+			text, err := marshaler.MarshalText()
+			return &dynamodb.AttributeValue{S: aws.String(string(text))}, true, err
+		}
+		return nil, false, nil
+	}
+	marshalBinary := func() (*dynamodb.AttributeValue, bool, error) {
+		if marshaler, ok := asInterface(value, binaryMarshalerType).(encoding.BinaryMarshaler); ok {
+			bin, err := marshaler.MarshalBinary()
+			return &dynamodb.AttributeValue{B: bin}, true, err
+		}
+		return nil, false, nil
+	}
+	if spec.asBinary {
+		if attr, ok, err := marshalBinary(); ok {
+			return attr, ok, err
+		}
+		return marshalText()
+	}
+	if attr, ok, err := marshalText(); ok {
+		return attr, ok, err
+	}
+	return marshalBinary()
+}
+
+// unmarshalTextOrBinary reads S attribute with encoding.TextUnmarshaler and B attribute with encoding.BinaryUnmarshaler
+func unmarshalTextOrBinary(value reflect.Value, attr *dynamodb.AttributeValue) (bool, error) {
+	if !value.CanAddr() {
+		return false, nil
+	}
+	if attr.S != nil {
+		if unmarshaler, ok := asInterface(value.Addr(), textUnmarshalerType).(encoding.TextUnmarshaler); ok {
+			return true, invalidValue(unmarshaler.UnmarshalText([]byte(*attr.S)))
+		}
+	}
+	if attr.B != nil {
+		if unmarshaler, ok := asInterface(value.Addr(), binaryUnmarshalerType).(encoding.BinaryUnmarshaler); ok {
+			return true, invalidValue(unmarshaler.UnmarshalBinary(attr.B))
+		}
+	}
+	return false, nil
+}
+
+// asInterface returns the value or the pointer to it implementing the interface type, nil otherwise;
+// the value is copied if its address is needed but can't be taken
+func asInterface(value reflect.Value, iface reflect.Type) interface{} {
+	if value.Kind() == reflect.Interface || (value.Kind() == reflect.Ptr && value.IsNil()) {
+		return nil
+	}
+	if value.Type().Implements(iface) {
+		return value.Interface()
+	}
+	if value.Kind() != reflect.Ptr && reflect.PtrTo(value.Type()).Implements(iface) {
+		return addressOf(value).Interface()
+	}
+	return nil
+}
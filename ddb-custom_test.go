@@ -0,0 +1,196 @@
+package ddbmarshal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type testMoney struct {
+	Cents    int64
+	Currency string
+}
+
+func (m testMoney) MarshalDdb() (*dynamodb.AttributeValue, error) {
+	return &dynamodb.AttributeValue{S: aws.String(fmt.Sprintf("%s:%d", m.Currency, m.Cents))}, nil
+}
+
+func (m *testMoney) UnmarshalDdb(attr *dynamodb.AttributeValue) error {
+	if attr.S == nil {
+		return errors.New("money is expected as a string")
+	}
+	_, err := fmt.Sscanf(*attr.S, "%3s:%d", &m.Currency, &m.Cents)
+	return err
+}
+
+type testPoint struct {
+	X, Y int
+}
+
+func (p *testPoint) MarshalDdb() (*dynamodb.AttributeValue, error) {
+	return &dynamodb.AttributeValue{NS: aws.StringSlice([]string{fmt.Sprint(p.X), fmt.Sprint(p.Y)})}, nil
+}
+
+func (p *testPoint) UnmarshalDdb(attr *dynamodb.AttributeValue) error {
+	if len(attr.NS) != 2 {
+		return errors.New("point is expected as two numbers")
+	}
+	_, err := fmt.Sscan(*attr.NS[0]+" "+*attr.NS[1], &p.X, &p.Y)
+	return err
+}
+
+type testCustom struct {
+	Price    testMoney            `ddb:"price"`
+	Discount *testMoney           `ddb:"discount"`
+	Totals   map[string]testMoney `ddb:"totals"`
+	History  []testMoney          `ddb:"history"`
+	Location testPoint            `ddb:"location"`
+	Path     []testPoint          `ddb:"path"`
+}
+
+func prepareCustomStruct() *testCustom {
+	return &testCustom{
+		Price:    testMoney{199, "USD"},
+		Discount: &testMoney{10, "USD"},
+		Totals:   map[string]testMoney{"net": {189, "USD"}},
+		History:  []testMoney{{150, "EUR"}},
+		Location: testPoint{1, 2},
+		Path:     []testPoint{{3, 4}},
+	}
+}
+
+func prepareCustomDdb() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"price":    {S: aws.String("USD:199")},
+		"discount": {S: aws.String("USD:10")},
+		"totals":   {M: map[string]*dynamodb.AttributeValue{"net": {S: aws.String("USD:189")}}},
+		"history":  {L: []*dynamodb.AttributeValue{{S: aws.String("EUR:150")}}},
+		"location": {NS: aws.StringSlice([]string{"1", "2"})},
+		"path":     {L: []*dynamodb.AttributeValue{{NS: aws.StringSlice([]string{"3", "4"})}}},
+	}
+}
+
+func TestDdbMarshaller_MarshalCustom(t *testing.T) {
+	me := NewMarshaller()
+	got, err := me.Marshal(prepareCustomStruct())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := prepareCustomDdb(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Marshal() got = %v, want %v", got, want)
+	}
+}
+
+func TestDdbMarshaller_UnmarshalCustom(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   map[string]*dynamodb.AttributeValue
+		wantErr  bool
+		wantData *testCustom
+	}{
+		{
+			name:     "happy",
+			source:   prepareCustomDdb(),
+			wantErr:  false,
+			wantData: prepareCustomStruct(),
+		},
+		{
+			name: "unmarshaler error is returned",
+			source: map[string]*dynamodb.AttributeValue{
+				"price": {N: aws.String("199")},
+			},
+			wantErr:  true,
+			wantData: &testCustom{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			me := NewMarshaller()
+			var got testCustom
+			if err := me.Unmarshal(&got, tt.source); (err != nil) != tt.wantErr {
+				t.Errorf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			} else if !tt.wantErr && !reflect.DeepEqual(&got, tt.wantData) {
+				t.Errorf("Unmarshal() got = %v, want %v", got, tt.wantData)
+			}
+		})
+	}
+}
+
+type testToken struct {
+	id uint32
+}
+
+func (t testToken) MarshalText() ([]byte, error) {
+	return []byte("tok-" + strconv.FormatUint(uint64(t.id), 10)), nil
+}
+
+func (t *testToken) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "tok-%d", &t.id)
+	return err
+}
+
+func (t testToken) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 4)
+	binary.BigEndian.PutUint32(data, t.id)
+	return data, nil
+}
+
+func (t *testToken) UnmarshalBinary(data []byte) error {
+	if len(data) != 4 {
+		return errors.New("4 bytes token is expected")
+	}
+	t.id = binary.BigEndian.Uint32(data)
+	return nil
+}
+
+type testTextBinary struct {
+	Addr    net.IP               `ddb:"addr"`
+	Token   testToken            `ddb:"token"`
+	Binary  testToken            `ddb:"binary,binary"`
+	Tokens  []testToken          `ddb:"tokens"`
+	ByToken map[string]testToken `ddb:"byToken,binary"`
+}
+
+func prepareTextBinaryStruct() *testTextBinary {
+	return &testTextBinary{
+		Addr:    net.ParseIP("10.0.0.1"),
+		Token:   testToken{7},
+		Binary:  testToken{258},
+		Tokens:  []testToken{{1}, {1}},
+		ByToken: map[string]testToken{"a": {1}},
+	}
+}
+
+func prepareTextBinaryDdb() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"addr":    {S: aws.String("10.0.0.1")},
+		"token":   {S: aws.String("tok-7")},
+		"binary":  {B: []byte{0, 0, 1, 2}},
+		"tokens":  {L: []*dynamodb.AttributeValue{{S: aws.String("tok-1")}, {S: aws.String("tok-1")}}},
+		"byToken": {M: map[string]*dynamodb.AttributeValue{"a": {B: []byte{0, 0, 0, 1}}}},
+	}
+}
+
+func TestDdbMarshaller_TextOrBinary(t *testing.T) {
+	me := NewMarshaller()
+	got, err := me.Marshal(prepareTextBinaryStruct())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := prepareTextBinaryDdb(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Marshal() got = %v, want %v", got, want)
+	}
+	var data testTextBinary
+	if err := me.Unmarshal(&data, got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if want := prepareTextBinaryStruct(); !reflect.DeepEqual(&data, want) {
+		t.Errorf("Unmarshal() got = %v, want %v", data, want)
+	}
+}
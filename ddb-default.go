@@ -0,0 +1,92 @@
+package ddbmarshal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// defaultSeparator separates the elements of the set or list in the "default=" literal, i.e. `ddb:"tags,default=a|b"`
+const defaultSeparator = "|"
+
+// SetMarshalDefaults makes fields with zero value to be stored with their "default=" value on marshal,
+// by default the defaults are applied on unmarshal only, for absent attributes
+func (marshaller *DdbMarshaller) SetMarshalDefaults(value bool) {
+	marshaller.marshalDefaults = value
+}
+
+// compileDefault converts the "default=" literal to the attribute and checks it is readable into the field type,
+// so invalid default is reported on the first use of the struct type
+func (me *DdbMarshaller) compileDefault(typ reflect.Type, spec specs) (*dynamodb.AttributeValue, error) {
+	attr, err := me.defaultAttribute(typ, spec.defaultValue, spec, true)
+	if err == nil {
+		err = me.unmarshalValue(reflect.New(typ).Elem(), attr, spec)
+	}
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("invalid default %q for %v: %v", spec.defaultValue, typ, err))
+	}
+	return attr, nil
+}
+
+// defaultAttribute builds the attribute from the literal the way it is read by unmarshalValue,
+// elements of the sets and lists are separated by defaultSeparator
+func (me *DdbMarshaller) defaultAttribute(typ reflect.Type, literal string, spec specs, top bool) (*dynamodb.AttributeValue, error) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch {
+	case typ == timeType:
+		if isNumericTimeFormat(me.timeFormatOf(spec)) {
+			return &dynamodb.AttributeValue{N: aws.String(literal)}, nil
+		}
+		return &dynamodb.AttributeValue{S: aws.String(literal)}, nil
+	case typ == durationType:
+		if _, err := strconv.ParseInt(literal, 10, 64); err == nil {
+			return &dynamodb.AttributeValue{N: aws.String(literal)}, nil
+		}
+		return &dynamodb.AttributeValue{S: aws.String(literal)}, nil
+	case isNumberType(typ), typ == numberType, typ == bigIntType, typ == bigFloatType, typ == bigRatType:
+		return &dynamodb.AttributeValue{N: aws.String(literal)}, nil
+	case typ.Kind() == reflect.Bool:
+		if val, err := strconv.ParseBool(literal); err != nil {
+			return nil, err
+		} else {
+			return &dynamodb.AttributeValue{BOOL: aws.Bool(val)}, nil
+		}
+	case typ.Kind() == reflect.String, reflect.PtrTo(typ).Implements(textUnmarshalerType):
+		return &dynamodb.AttributeValue{S: aws.String(literal)}, nil
+	case top && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) && !isBytesType(typ):
+		return me.defaultList(typ.Elem(), literal, spec)
+	case top && isSetMapType(typ):
+		return me.defaultList(typ.Key(), literal, spec)
+	}
+	return nil, unsupportedType(typ)
+}
+
+func (me *DdbMarshaller) defaultList(elemType reflect.Type, literal string, spec specs) (*dynamodb.AttributeValue, error) {
+	result := &dynamodb.AttributeValue{L: []*dynamodb.AttributeValue{}}
+	if literal == "" {
+		return result, nil
+	}
+	for _, item := range strings.Split(literal, defaultSeparator) {
+		if elem, err := me.defaultAttribute(elemType, item, spec, false); err != nil {
+			return nil, err
+		} else {
+			result.L = append(result.L, elem)
+		}
+	}
+	return result, nil
+}
+
+// marshalDefault stores the default of the field same way as the field value would be stored
+func (me *DdbMarshaller) marshalDefault(field structField, typ reflect.Type) (*dynamodb.AttributeValue, error) {
+	value := reflect.New(typ).Elem()
+	if err := me.unmarshalValue(value, field.defaultAttr, field.spec); err != nil {
+		return nil, err
+	}
+	return me.ddbBasicMarshal(value, field.spec)
+}
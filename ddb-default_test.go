@@ -0,0 +1,121 @@
+package ddbmarshal
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testDefaults struct {
+	Status    testStatus    `ddb:"status,default=active"`
+	Retries   int           `ddb:"retries,default=3"`
+	Ratio     *float64      `ddb:"ratio,default=0.5"`
+	Enabled   bool          `ddb:"enabled,default=true"`
+	Tags      []string      `ddb:"tags,default=a|b"`
+	Codes     Set[int]      `ddb:"codes,default=1|2"`
+	Since     time.Time     `ddb:"since,rfc3339,default=2020-01-02T03:04:05Z"`
+	Expires   time.Time     `ddb:"expires,ttl-ts,default=1600000000"`
+	Timeout   time.Duration `ddb:"timeout,default=1m30s"`
+	Token     testToken     `ddb:"token,default=tok-7"`
+	Empty     []string      `ddb:"empty,default="`
+	NoDefault string        `ddb:"noDefault"`
+}
+
+func prepareDefaultsStruct() *testDefaults {
+	ratio := 0.5
+	return &testDefaults{
+		Status:  "active",
+		Retries: 3,
+		Ratio:   &ratio,
+		Enabled: true,
+		Tags:    []string{"a", "b"},
+		Codes:   NewSet(1, 2),
+		Since:   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Expires: time.Unix(1600000000, 0).UTC(),
+		Timeout: 90 * time.Second,
+		Token:   testToken{7},
+		Empty:   []string{},
+	}
+}
+
+func TestDdbMarshaller_UnmarshalDefaults(t *testing.T) {
+	me := NewMarshaller()
+	var data testDefaults
+	if err := me.Unmarshal(&data, map[string]*dynamodb.AttributeValue{}); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if want := prepareDefaultsStruct(); !reflect.DeepEqual(&data, want) {
+		t.Errorf("Unmarshal() got = %v, want %v", data, want)
+	}
+	if err := me.Unmarshal(&data, map[string]*dynamodb.AttributeValue{"retries": {N: aws.String("0")}}); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if data.Retries != 0 {
+		t.Errorf("Unmarshal() default is applied to present attribute: %v", data.Retries)
+	}
+}
+
+func TestDdbMarshaller_SetMarshalDefaults(t *testing.T) {
+	me := NewMarshaller()
+	got, err := me.Marshal(&testDefaults{Retries: 5})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if got["status"].S == nil || *got["status"].S != "" || got["retries"].N == nil || *got["retries"].N != "5" {
+		t.Errorf("Marshal() defaults are applied without SetMarshalDefaults: %v", got)
+	}
+	me.SetMarshalDefaults(true)
+	if got, err = me.Marshal(&testDefaults{Retries: 5}); err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want, err := me.Marshal(prepareDefaultsStruct())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want["retries"] = &dynamodb.AttributeValue{N: aws.String("5")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Marshal() got = %v, want %v", got, want)
+	}
+	if got["codes"].NS == nil || got["tags"].SS == nil {
+		t.Errorf("Marshal() default sets are not stored as sets: %v", got)
+	}
+}
+
+func TestDdbMarshaller_InvalidDefaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		target interface{}
+	}{
+		{"not a number", &struct {
+			V int `ddb:"v,default=many"`
+		}{}},
+		{"out of range", &struct {
+			V int8 `ddb:"v,default=300"`
+		}{}},
+		{"not a bool", &struct {
+			V bool `ddb:"v,default=yes"`
+		}{}},
+		{"not a time", &struct {
+			V time.Time `ddb:"v,rfc3339,default=yesterday"`
+		}{}},
+		{"bad set element", &struct {
+			V []int `ddb:"v,default=1|x"`
+		}{}},
+		{"unsupported type", &struct {
+			V testLineItem `ddb:"v,default=x"`
+		}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			me := NewMarshaller()
+			if err := me.Unmarshal(tt.target, map[string]*dynamodb.AttributeValue{"v": {NULL: aws.Bool(true)}}); err == nil {
+				t.Errorf("Unmarshal() error expected")
+			}
+			if _, err := me.Marshal(tt.target); err == nil {
+				t.Errorf("Marshal() error expected")
+			}
+		})
+	}
+}
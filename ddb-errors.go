@@ -0,0 +1,211 @@
+package ddbmarshal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrMissingRequired is reported for the absent attribute of the field tagged as "required"
+	ErrMissingRequired = errors.New("missing required attribute")
+	// ErrTypeMismatch is reported when the attribute type doesn't match the go type, see UnmarshalTypeError
+	ErrTypeMismatch = errors.New("attribute type mismatch")
+	// ErrInvalidValue is reported when the attribute can't be parsed into the go type, i.e. number out of range
+	ErrInvalidValue = errors.New("invalid attribute value")
+	// ErrUnsupportedType is reported for the go types which can't be (un)marshalled
+	ErrUnsupportedType = errors.New("unsupported type")
+	// ErrValidation is matched by ValidationErrors and ValidationError, the violations of the validation rules
+	ErrValidation = errors.New("validation failed")
+	// ErrUnknownAttribute is reported in the strict mode for the attributes not mapped to any field, see UnknownAttributesError
+	ErrUnknownAttribute = errors.New("unknown attribute")
+)
+
+// UnmarshalError is the error of unmarshalling the attribute, Err is the cause, i.e. ErrMissingRequired
+type UnmarshalError struct {
+	Field     string // go field path, i.e. Address.Lines[2]
+	Attribute string // attribute path, i.e. address.lines[2]
+	Err       error
+}
+
+func (e *UnmarshalError) Error() string {
+	return fmt.Sprintf("can't unmarshal attribute %q into field %s: %v", e.Attribute, e.Field, e.Err)
+}
+
+func (e *UnmarshalError) Unwrap() error {
+	return e.Err
+}
+
+// UnmarshalTypeError is the error of the attribute type not matching the go type, it matches ErrTypeMismatch
+type UnmarshalTypeError struct {
+	Field     string       // go field path, i.e. Address.Lines[2]
+	Attribute string       // attribute path, i.e. address.lines[2]
+	Expected  string       // expected attribute types, i.e. "N" or "S or B"
+	Actual    string       // attribute type, i.e. "SS", empty for attribute without value
+	Type      reflect.Type // go type of the value
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	actual := e.Actual
+	if actual == "" {
+		actual = "empty"
+	}
+	return fmt.Sprintf("can't unmarshal %s attribute %q into field %s of type %v: %s expected", actual, e.Attribute, e.Field, e.Type, e.Expected)
+}
+
+func (e *UnmarshalTypeError) Is(target error) bool {
+	return target == ErrTypeMismatch
+}
+
+// valueError keeps the cause (i.e. *strconv.NumError) available to errors.As while matching ErrInvalidValue
+type valueError struct {
+	err error
+}
+
+func (e *valueError) Error() string {
+	return e.err.Error()
+}
+
+func (e *valueError) Unwrap() error {
+	return e.err
+}
+
+func (e *valueError) Is(target error) bool {
+	return target == ErrInvalidValue
+}
+
+func invalidValue(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &valueError{err: err}
+}
+
+func typeMismatch(typ reflect.Type, attr *dynamodb.AttributeValue, expected string) error {
+	return &UnmarshalTypeError{Expected: expected, Actual: attributeType(attr), Type: typ}
+}
+
+func unsupportedType(typ reflect.Type) error {
+	return fmt.Errorf("%w %v", ErrUnsupportedType, typ)
+}
+
+// attributeType returns DynamoDB type of the attribute, empty if none of the values is set
+func attributeType(attr *dynamodb.AttributeValue) string {
+	switch {
+	case attr == nil:
+		return ""
+	case aws.BoolValue(attr.NULL):
+		return "NULL"
+	case attr.S != nil:
+		return "S"
+	case attr.N != nil:
+		return "N"
+	case attr.B != nil:
+		return "B"
+	case attr.BOOL != nil:
+		return "BOOL"
+	case attr.SS != nil:
+		return "SS"
+	case attr.NS != nil:
+		return "NS"
+	case attr.BS != nil:
+		return "BS"
+	case attr.M != nil:
+		return "M"
+	case attr.L != nil:
+		return "L"
+	}
+	return ""
+}
+
+// withPath prepends the field and attribute path segments to the path of the error,
+// errors without path are wrapped into UnmarshalError
+func withPath(err error, field, attribute string) error {
+	var typeErr *UnmarshalTypeError
+	var unmarshalErr *UnmarshalError
+	var validationErr *ValidationError
+	switch {
+	case err == nil || field == "" && attribute == "":
+		return err
+	case errors.As(err, &validationErr):
+		validationErr.Field = joinPath(field, validationErr.Field)
+		validationErr.Attribute = joinPath(attribute, validationErr.Attribute)
+	case errors.As(err, &typeErr):
+		typeErr.Field = joinPath(field, typeErr.Field)
+		typeErr.Attribute = joinPath(attribute, typeErr.Attribute)
+	case errors.As(err, &unmarshalErr):
+		unmarshalErr.Field = joinPath(field, unmarshalErr.Field)
+		unmarshalErr.Attribute = joinPath(attribute, unmarshalErr.Attribute)
+	default:
+		return &UnmarshalError{Field: field, Attribute: attribute, Err: err}
+	}
+	return err
+}
+
+func joinPath(parent, child string) string {
+	switch {
+	case parent == "":
+		return child
+	case child == "":
+		return parent
+	case strings.HasPrefix(child, "["):
+		return parent + child
+	}
+	return parent + "." + child
+}
+
+// deferredErrors collects the errors which don't stop unmarshalling: unknown attributes and violations
+// (missing required attributes and validation errors), so all of them are reported at once
+type deferredErrors struct {
+	unknown    []string
+	violations ValidationErrors
+}
+
+// collect keeps the deferred errors reported by err adding the path to them, other errors are returned with the path
+func (d *deferredErrors) collect(err error, field, attribute string) error {
+	var violations ValidationErrors
+	var unknownErr *UnknownAttributesError
+	switch {
+	case errors.As(err, &violations):
+		for _, violation := range violations {
+			if errors.As(violation, &unknownErr) {
+				d.addUnknown(unknownErr, attribute)
+			} else {
+				d.violations = append(d.violations, withPath(violation, field, attribute))
+			}
+		}
+	case errors.As(err, &unknownErr):
+		d.addUnknown(unknownErr, attribute)
+	default:
+		return withPath(err, field, attribute)
+	}
+	return nil
+}
+
+func (d *deferredErrors) addUnknown(err *UnknownAttributesError, attribute string) {
+	for _, name := range err.Attributes {
+		d.unknown = append(d.unknown, joinPath(attribute, name))
+	}
+}
+
+// err returns UnknownAttributesError, ValidationErrors (including unknown attributes if any) or nil
+func (d *deferredErrors) err() error {
+	var unknown *UnknownAttributesError
+	if len(d.unknown) > 0 {
+		sort.Strings(d.unknown)
+		unknown = &UnknownAttributesError{Attributes: d.unknown}
+	}
+	switch {
+	case len(d.violations) > 0 && unknown != nil:
+		return append(d.violations, unknown)
+	case len(d.violations) > 0:
+		return d.violations
+	case unknown != nil:
+		return unknown
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package ddbmarshal
+
+import (
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type testErrAddress struct {
+	Lines []int          `ddb:"lines,list"`
+	Tags  map[string]int `ddb:"tags"`
+}
+
+type testErrItem struct {
+	Id      string          `ddb:"id,required"`
+	Address *testErrAddress `ddb:"address"`
+	Small   int8            `ddb:"small"`
+	Flag    bool            `ddb:"flag"`
+	Channel chan int        `ddb:"channel"`
+}
+
+func TestDdbMarshaller_UnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    map[string]*dynamodb.AttributeValue
+		sentinel  error
+		field     string
+		attribute string
+	}{
+		{
+			name:      "missing required",
+			source:    map[string]*dynamodb.AttributeValue{},
+			sentinel:  ErrMissingRequired,
+			field:     "Id",
+			attribute: "id",
+		},
+		{
+			name: "type mismatch in the list",
+			source: map[string]*dynamodb.AttributeValue{
+				"id": {S: aws.String("x")},
+				"address": {M: map[string]*dynamodb.AttributeValue{
+					"lines": {L: []*dynamodb.AttributeValue{{N: aws.String("1")}, {N: aws.String("2")}, {S: aws.String("3")}}},
+				}},
+			},
+			sentinel:  ErrTypeMismatch,
+			field:     "Address.Lines[2]",
+			attribute: "address.lines[2]",
+		},
+		{
+			name: "invalid value in the map",
+			source: map[string]*dynamodb.AttributeValue{
+				"id": {S: aws.String("x")},
+				"address": {M: map[string]*dynamodb.AttributeValue{
+					"tags": {M: map[string]*dynamodb.AttributeValue{"k": {N: aws.String("1.5")}}},
+				}},
+			},
+			sentinel:  ErrInvalidValue,
+			field:     "Address.Tags[k]",
+			attribute: "address.tags.k",
+		},
+		{
+			name: "overflow",
+			source: map[string]*dynamodb.AttributeValue{
+				"id":    {S: aws.String("x")},
+				"small": {N: aws.String("300")},
+			},
+			sentinel:  ErrInvalidValue,
+			field:     "Small",
+			attribute: "small",
+		},
+		{
+			name: "string instead of bool",
+			source: map[string]*dynamodb.AttributeValue{
+				"id":   {S: aws.String("x")},
+				"flag": {S: aws.String("true")},
+			},
+			sentinel:  ErrTypeMismatch,
+			field:     "Flag",
+			attribute: "flag",
+		},
+		{
+			name: "list instead of map",
+			source: map[string]*dynamodb.AttributeValue{
+				"id":      {S: aws.String("x")},
+				"address": {L: []*dynamodb.AttributeValue{}},
+			},
+			sentinel:  ErrTypeMismatch,
+			field:     "Address",
+			attribute: "address",
+		},
+		{
+			name: "unsupported type",
+			source: map[string]*dynamodb.AttributeValue{
+				"id":      {S: aws.String("x")},
+				"channel": {N: aws.String("1")},
+			},
+			sentinel:  ErrUnsupportedType,
+			field:     "Channel",
+			attribute: "channel",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			me := NewMarshaller()
+			err := me.Unmarshal(&testErrItem{}, tt.source)
+			if !errors.Is(err, tt.sentinel) {
+				t.Fatalf("Unmarshal() error = %v, want %v", err, tt.sentinel)
+			}
+			var field, attribute string
+			var typeErr *UnmarshalTypeError
+			var unmarshalErr *UnmarshalError
+			switch {
+			case errors.As(err, &typeErr):
+				field, attribute = typeErr.Field, typeErr.Attribute
+			case errors.As(err, &unmarshalErr):
+				field, attribute = unmarshalErr.Field, unmarshalErr.Attribute
+			default:
+				t.Fatalf("Unmarshal() error = %v has no path", err)
+			}
+			if field != tt.field || attribute != tt.attribute {
+				t.Errorf("Unmarshal() error path = %s %s, want %s %s", field, attribute, tt.field, tt.attribute)
+			}
+		})
+	}
+}
+
+func TestUnmarshalTypeError(t *testing.T) {
+	me := NewMarshaller()
+	err := me.Unmarshal(&testErrItem{}, map[string]*dynamodb.AttributeValue{
+		"id":    {S: aws.String("x")},
+		"small": {SS: aws.StringSlice([]string{"1"})},
+	})
+	var typeErr *UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("Unmarshal() error = %v, UnmarshalTypeError expected", err)
+	}
+	want := &UnmarshalTypeError{Field: "Small", Attribute: "small", Expected: "N", Actual: "SS", Type: reflect.TypeOf(int8(0))}
+	if !reflect.DeepEqual(typeErr, want) {
+		t.Errorf("Unmarshal() error = %#v, want %#v", typeErr, want)
+	}
+}
+
+func TestDdbMarshaller_UnmarshalErrorCause(t *testing.T) {
+	me := NewMarshaller()
+	err := me.Unmarshal(&testErrItem{}, map[string]*dynamodb.AttributeValue{
+		"id":    {S: aws.String("x")},
+		"small": {N: aws.String("300")},
+	})
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) || !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("Unmarshal() error = %v, cause is not available", err)
+	}
+}
+
+func TestDdbMarshaller_UnmarshalNilElement(t *testing.T) {
+	me := NewMarshaller()
+	var data testErrItem
+	err := me.Unmarshal(&data, map[string]*dynamodb.AttributeValue{
+		"id": {S: aws.String("x")},
+		"address": {M: map[string]*dynamodb.AttributeValue{
+			"lines": {L: []*dynamodb.AttributeValue{nil, {N: aws.String("2")}}},
+			"tags":  {M: map[string]*dynamodb.AttributeValue{"k": nil}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := &testErrAddress{Lines: []int{0, 2}, Tags: map[string]int{"k": 0}}
+	if !reflect.DeepEqual(data.Address, want) {
+		t.Errorf("Unmarshal() got = %v, want %v", data.Address, want)
+	}
+}
@@ -0,0 +1,229 @@
+package ddbmarshal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// structField is a field of the struct stored as an attribute, index is the path to the field
+// through the embedded (or inline) structs as used by reflect.Value.FieldByIndex;
+// the field tagged as "remain" keeps the attributes not mapped to other fields, its name is empty;
+// defaultAttr is the "default=" value of the field
+type structField struct {
+	index       []int
+	goName      string
+	spec        specs
+	defaultAttr *dynamodb.AttributeValue
+}
+
+var remainType = reflect.TypeOf(map[string]*dynamodb.AttributeValue{})
+
+type fieldCacheKey struct {
+	typ    reflect.Type
+	prefix string
+}
+
+// structFields returns the attribute fields of the struct type, they are resolved once per type and cached,
+// the cache is safe for concurrent use and is reset when the options affecting the names are changed
+func (me *DdbMarshaller) structFields(typ reflect.Type, prefix string) ([]structField, error) {
+	key := fieldCacheKey{typ: typ, prefix: prefix}
+	if fields, ok := me.fieldCache.Load(key); ok {
+		return fields.([]structField), nil
+	}
+	fields, err := me.resolveStructFields(typ, prefix)
+	if err != nil {
+		return nil, err
+	}
+	me.fieldCache.Store(key, fields)
+	return fields, nil
+}
+
+func (me *DdbMarshaller) resetFieldCache() {
+	me.fieldCache.Range(func(key, _ interface{}) bool {
+		me.fieldCache.Delete(key)
+		return true
+	})
+}
+
+// resolveStructFields lists the attribute fields of the struct type following Go's field promotion rules:
+// fields of the embedded structs (and pointers to them) without attribute name as well as of the fields
+// tagged as "inline" are promoted to the parent, shallower fields hide the deeper ones with the same name,
+// while the same name at the same depth is an error.
+// This is the only place resolving attribute names, so Marshal, Unmarshal and GetUnmarshaledFields
+// use the same mapping: untagged public fields are included with SetMarshalAllPublicFields (named by
+// SetNamingStrategy or decapitalized with SetDecapitalizeUntaggedFieldNames), the prefix is added to all the names.
+// The "remain" field follows the same promotion rules
+func (me *DdbMarshaller) resolveStructFields(typ reflect.Type, prefix string) ([]structField, error) {
+	type pending struct {
+		typ   reflect.Type
+		index []int
+	}
+	var result []structField
+	names := make(map[string]bool)
+	visited := make(map[reflect.Type]bool)
+	hasRemain := false
+	for next := []pending{{typ: typ}}; len(next) > 0; {
+		current := next
+		next = nil
+		level := make(map[string]structField)
+		var remain *structField
+		for _, p := range current {
+			if visited[p.typ] {
+				continue
+			}
+			for i, I := 0, p.typ.NumField(); i < I; i++ {
+				fieldType := p.typ.Field(i)
+				index := append(append(make([]int, 0, len(p.index)+1), p.index...), i)
+				ddbSpecStr, tagged := fieldType.Tag.Lookup(TagDdb)
+				var ddbSpecs specs
+				if tagged {
+					var err error
+					if ddbSpecs, err = ParseDdbTag(ddbSpecStr); err != nil {
+						return nil, err
+					}
+				}
+				if inner, ok := promotedStruct(fieldType, ddbSpecs); ok {
+					if ddbSpecs.inline && inner == nil {
+						return nil, errors.New(fmt.Sprintf("can't inline field %s of type %v", fieldType.Name, fieldType.Type))
+					}
+					if inner != nil {
+						next = append(next, pending{typ: inner, index: index})
+					}
+					continue
+				}
+				if !fieldType.IsExported() {
+					if tagged {
+						return nil, errors.New("can't use ddb field for unexported fieldType " + fieldType.Name)
+					}
+					continue
+				}
+				if ddbSpecs.remain {
+					if fieldType.Type != remainType {
+						return nil, errors.New(fmt.Sprintf("field %s tagged as %q must be of type %v", fieldType.Name, TagItemRemain, remainType))
+					}
+					if remain != nil {
+						return nil, errors.New(fmt.Sprintf("conflicting %q fields %s and %s of %v", TagItemRemain, remain.goName, fieldType.Name, typ))
+					}
+					remain = &structField{index: index, goName: fieldType.Name, spec: specs{remain: true}}
+					continue
+				}
+				if !tagged {
+					if !me.marshalAllPublicFields {
+						continue
+					}
+					ddbSpecs = specs{name: me.attributeName(fieldType.Name, false)}
+				} else if ddbSpecs.name == "" {
+					ddbSpecs.name = me.attributeName(fieldType.Name, true)
+				}
+				ddbSpecs.name = prefix + ddbSpecs.name
+				if names[ddbSpecs.name] {
+					continue
+				}
+				if other, ok := level[ddbSpecs.name]; ok {
+					return nil, errors.New(fmt.Sprintf("conflicting attribute name %s for fields %s and %s of %v",
+						ddbSpecs.name, other.goName, fieldType.Name, typ))
+				}
+				field := structField{index: index, goName: fieldType.Name, spec: ddbSpecs}
+				if ddbSpecs.rules != nil {
+					if err := compileRules(fieldType.Type, ddbSpecs.rules); err != nil {
+						return nil, errors.New(fmt.Sprintf("field %s of %v: %v", fieldType.Name, typ, err))
+					}
+				}
+				if ddbSpecs.hasDefault {
+					var err error
+					if field.defaultAttr, err = me.compileDefault(fieldType.Type, ddbSpecs); err != nil {
+						return nil, errors.New(fmt.Sprintf("field %s of %v: %v", fieldType.Name, typ, err))
+					}
+				}
+				level[ddbSpecs.name] = field
+			}
+		}
+		for _, p := range current {
+			visited[p.typ] = true
+		}
+		for name, field := range level {
+			names[name] = true
+			result = append(result, field)
+		}
+		if remain != nil && !hasRemain {
+			hasRemain = true
+			result = append(result, *remain)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return lessIndex(result[i].index, result[j].index)
+	})
+	return result, nil
+}
+
+// attributeName returns the attribute name of the field without name in the tag, or without the tag at all
+func (me *DdbMarshaller) attributeName(fieldName string, tagged bool) string {
+	switch {
+	case me.namingStrategy != nil:
+		return me.namingStrategy(fieldName)
+	case me.decapitalizeUntaggedFields && !tagged:
+		return strings.ToLower(fieldName[0:1]) + fieldName[1:]
+	}
+	return fieldName
+}
+
+// promotedStruct reports whether the fields of the field are promoted to the parent struct,
+// the struct type to walk is nil if the field can't be promoted
+func promotedStruct(fieldType reflect.StructField, spec specs) (reflect.Type, bool) {
+	if !spec.inline && !(fieldType.Anonymous && spec.name == "") {
+		return nil, false
+	}
+	typ := fieldType.Type
+	if typ.Kind() == reflect.Ptr {
+		if !fieldType.IsExported() {
+			// pointer to unexported struct can't be allocated on unmarshal
+			return nil, !spec.inline && typ.Elem().Kind() == reflect.Struct
+		}
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || typ == timeType {
+		return nil, spec.inline
+	}
+	return typ, true
+}
+
+func lessIndex(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// fieldForRead returns the field by index, ok is false if one of the embedded pointers on the path is nil
+func fieldForRead(value reflect.Value, index []int) (reflect.Value, bool) {
+	for _, i := range index {
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return reflect.Value{}, false
+			}
+			value = value.Elem()
+		}
+		value = value.Field(i)
+	}
+	return value, true
+}
+
+// fieldForWrite returns the field by index allocating the nil embedded pointers on the path
+func fieldForWrite(value reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		value = value.Field(i)
+	}
+	return value
+}
@@ -0,0 +1,405 @@
+package ddbmarshal
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type testKeyFields struct {
+	Pk string `ddb:"pk,hash-key"`
+	Sk string `ddb:"sk,range-key"`
+}
+
+type testAuditFields struct {
+	CreatedBy string `ddb:"createdBy"`
+	Version   int    `ddb:"version"`
+}
+
+type testVersioned struct {
+	Version int `ddb:"version"`
+}
+
+type testInternal struct {
+	Note string `ddb:"note"`
+}
+
+type testEmbedded struct {
+	Keys testKeyFields `ddb:",inline"`
+	testAuditFields
+	testInternal
+	Version int             `ddb:"version"`
+	Payload testAddress     `ddb:",inline"`
+	Named   testAuditFields `ddb:"named"`
+	Name    string          `ddb:"name"`
+}
+
+type testEmbeddedPointer struct {
+	*TestExportedKeys
+	Name string `ddb:"name"`
+}
+
+type TestExportedKeys struct {
+	Pk string `ddb:"pk"`
+}
+
+type testConflict struct {
+	testAuditFields
+	testVersioned
+}
+
+type testDuplicate struct {
+	First  string `ddb:"name"`
+	Second string `ddb:"name"`
+}
+
+type testInlineScalar struct {
+	Name string `ddb:"name,inline"`
+}
+
+func prepareEmbeddedStruct() *testEmbedded {
+	return &testEmbedded{
+		Keys:            testKeyFields{Pk: "p", Sk: "s"},
+		testAuditFields: testAuditFields{CreatedBy: "me"},
+		testInternal:    testInternal{Note: "n"},
+		Version:         3,
+		Payload:         testAddress{Street: "Main", Zip: 12345, Tags: map[string]string{"k": "v"}},
+		Named:           testAuditFields{CreatedBy: "you", Version: 1},
+		Name:            "x",
+	}
+}
+
+func prepareEmbeddedDdb() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"pk":        {S: aws.String("p")},
+		"sk":        {S: aws.String("s")},
+		"createdBy": {S: aws.String("me")},
+		"note":      {S: aws.String("n")},
+		"version":   {N: aws.String("3")},
+		"street":    {S: aws.String("Main")},
+		"zip":       {N: aws.String("12345")},
+		"tags":      {M: map[string]*dynamodb.AttributeValue{"k": {S: aws.String("v")}}},
+		"named": {M: map[string]*dynamodb.AttributeValue{
+			"createdBy": {S: aws.String("you")},
+			"version":   {N: aws.String("1")},
+		}},
+		"name": {S: aws.String("x")},
+	}
+}
+
+func TestDdbMarshaller_EmbeddedStructs(t *testing.T) {
+	me := NewMarshaller()
+	got, err := me.Marshal(prepareEmbeddedStruct())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := prepareEmbeddedDdb(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Marshal() got = %v, want %v", got, want)
+	}
+	var data testEmbedded
+	if err := me.Unmarshal(&data, got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if want := prepareEmbeddedStruct(); !reflect.DeepEqual(&data, want) {
+		t.Errorf("Unmarshal() got = %v, want %v", data, want)
+	}
+}
+
+func TestDdbMarshaller_EmbeddedPointer(t *testing.T) {
+	me := NewMarshaller()
+	tests := []struct {
+		name string
+		data *testEmbeddedPointer
+		ddb  map[string]*dynamodb.AttributeValue
+	}{
+		{
+			name: "nil pointer is omitted",
+			data: &testEmbeddedPointer{Name: "x"},
+			ddb:  map[string]*dynamodb.AttributeValue{"name": {S: aws.String("x")}},
+		},
+		{
+			name: "pointer is allocated",
+			data: &testEmbeddedPointer{TestExportedKeys: &TestExportedKeys{Pk: "p"}, Name: "x"},
+			ddb: map[string]*dynamodb.AttributeValue{
+				"pk":   {S: aws.String("p")},
+				"name": {S: aws.String("x")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := me.Marshal(tt.data)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.ddb) {
+				t.Errorf("Marshal() got = %v, want %v", got, tt.ddb)
+			}
+			var data testEmbeddedPointer
+			if err := me.Unmarshal(&data, tt.ddb); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(&data, tt.data) {
+				t.Errorf("Unmarshal() got = %v, want %v", data, tt.data)
+			}
+		})
+	}
+}
+
+func TestDdbMarshaller_FieldErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		target interface{}
+	}{
+		{"conflict at the same depth", &testConflict{}},
+		{"duplicate attribute name", &testDuplicate{}},
+		{"inline of not a struct", &testInlineScalar{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			me := NewMarshaller()
+			if _, err := me.Marshal(tt.target); err == nil {
+				t.Errorf("Marshal() error expected")
+			}
+			if err := me.Unmarshal(tt.target, map[string]*dynamodb.AttributeValue{}); err == nil {
+				t.Errorf("Unmarshal() error expected")
+			}
+		})
+	}
+}
+
+func TestDdbMarshaller_GetUnmarshaledFieldsEmbedded(t *testing.T) {
+	me := NewMarshaller()
+	response := map[string]*dynamodb.AttributeValue{
+		"pk":    {S: aws.String("p")},
+		"name":  {S: aws.String("x")},
+		"extra": {S: aws.String("e")},
+	}
+	got, err := me.GetUnmarshaledFields(&testEmbeddedPointer{}, response)
+	if err != nil {
+		t.Fatalf("GetUnmarshaledFields() error = %v", err)
+	}
+	if want := map[string]*dynamodb.AttributeValue{"extra": {S: aws.String("e")}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetUnmarshaledFields() got = %v, want %v", got, want)
+	}
+}
+
+type testOptions struct {
+	testAuditFields
+	Id      string            `ddb:"id,hash-key"`
+	Unnamed int               `ddb:",omitempty"`
+	Counter int64             // untagged
+	Nested  testKeyFields     // untagged, nested names are not affected by the prefix
+	Labels  map[string]string // untagged
+}
+
+func TestDdbMarshaller_OptionsRoundTrip(t *testing.T) {
+	source := &testOptions{
+		testAuditFields: testAuditFields{CreatedBy: "me", Version: 2},
+		Id:              "id",
+		Unnamed:         7,
+		Counter:         42,
+		Nested:          testKeyFields{Pk: "p", Sk: "s"},
+		Labels:          map[string]string{"k": "v"},
+	}
+	for _, allPublic := range []bool{false, true} {
+		for _, decapitalize := range []bool{false, true} {
+			for _, prefix := range []string{"", "app_"} {
+				me := NewMarshaller()
+				me.SetMarshalAllPublicFields(allPublic)
+				me.SetDecapitalizeUntaggedFieldNames(decapitalize)
+				me.SetFieldNamePrefix(prefix)
+				t.Run(fmt.Sprintf("all=%v decapitalize=%v prefix=%q", allPublic, decapitalize, prefix), func(t *testing.T) {
+					item, err := me.Marshal(source)
+					if err != nil {
+						t.Fatalf("Marshal() error = %v", err)
+					}
+					if item[prefix+"id"] == nil || item[prefix+"createdBy"] == nil || item[prefix+"Unnamed"] == nil {
+						t.Errorf("Marshal() tagged fields are not prefixed: %v", item)
+					}
+					var data testOptions
+					if err := me.Unmarshal(&data, item); err != nil {
+						t.Fatalf("Unmarshal() error = %v", err)
+					}
+					want := *source
+					if !allPublic {
+						want.Counter, want.Nested, want.Labels = 0, testKeyFields{}, nil
+					}
+					if !reflect.DeepEqual(data, want) {
+						t.Errorf("Unmarshal() got = %v, want %v", data, want)
+					}
+					item["extra"] = &dynamodb.AttributeValue{S: aws.String("e")}
+					rest, err := me.GetUnmarshaledFields(&data, item)
+					if err != nil {
+						t.Fatalf("GetUnmarshaledFields() error = %v", err)
+					}
+					if want := map[string]*dynamodb.AttributeValue{"extra": item["extra"]}; !reflect.DeepEqual(rest, want) {
+						t.Errorf("GetUnmarshaledFields() got = %v, want %v", rest, want)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestDdbMarshaller_FieldCacheReset(t *testing.T) {
+	me := NewMarshaller()
+	if _, err := me.Marshal(&testOptions{Counter: 1}); err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	me.SetMarshalAllPublicFields(true)
+	item, err := me.Marshal(&testOptions{Counter: 1})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if item["Counter"] == nil {
+		t.Errorf("Marshal() cached fields are used after option change: %v", item)
+	}
+}
+
+func TestDdbMarshaller_ConcurrentUse(t *testing.T) {
+	me := NewMarshaller()
+	item := prepareEmbeddedDdb()
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var data testEmbedded
+			if err := me.Unmarshal(&data, item); err != nil {
+				errs <- err
+				return
+			}
+			if got, err := me.Marshal(&data); err != nil {
+				errs <- err
+			} else if !reflect.DeepEqual(got, item) {
+				errs <- fmt.Errorf("Marshal() got = %v, want %v", got, item)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func benchmarkFieldCache(b *testing.B, run func(me *DdbMarshaller) error) {
+	for _, cached := range []bool{true, false} {
+		b.Run(fmt.Sprintf("cached=%v", cached), func(b *testing.B) {
+			me := NewMarshaller()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if !cached {
+					me.resetFieldCache()
+				}
+				if err := run(me); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDdbMarshaller_Marshal(b *testing.B) {
+	source := prepareEmbeddedStruct()
+	benchmarkFieldCache(b, func(me *DdbMarshaller) error {
+		_, err := me.Marshal(source)
+		return err
+	})
+}
+
+func BenchmarkDdbMarshaller_Unmarshal(b *testing.B) {
+	item := prepareEmbeddedDdb()
+	benchmarkFieldCache(b, func(me *DdbMarshaller) error {
+		var data testEmbedded
+		return me.Unmarshal(&data, item)
+	})
+}
+
+func BenchmarkDdbMarshaller_GetUnmarshaledFields(b *testing.B) {
+	item := prepareEmbeddedDdb()
+	benchmarkFieldCache(b, func(me *DdbMarshaller) error {
+		_, err := me.GetUnmarshaledFields(&testEmbedded{}, item)
+		return err
+	})
+}
+
+type testRemainNested struct {
+	Street string                              `ddb:"street"`
+	Rest   map[string]*dynamodb.AttributeValue `ddb:",remain"`
+}
+
+type testRemain struct {
+	Id      string                              `ddb:"id,hash-key"`
+	Address testRemainNested                    `ddb:"address"`
+	Rest    map[string]*dynamodb.AttributeValue `ddb:",remain"`
+}
+
+type testRemainWrongType struct {
+	Rest map[string]string `ddb:",remain"`
+}
+
+type testRemainTwice struct {
+	Rest  map[string]*dynamodb.AttributeValue `ddb:",remain"`
+	Other map[string]*dynamodb.AttributeValue `ddb:",remain"`
+}
+
+func TestDdbMarshaller_Remain(t *testing.T) {
+	item := map[string]*dynamodb.AttributeValue{
+		"id":    {S: aws.String("x")},
+		"owner": {S: aws.String("billing")},
+		"address": {M: map[string]*dynamodb.AttributeValue{
+			"street": {S: aws.String("Main")},
+			"zip":    {N: aws.String("1")},
+		}},
+	}
+	me := NewMarshaller()
+	me.SetStrictUnmarshal(true)
+	var data testRemain
+	if err := me.Unmarshal(&data, item); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := &testRemain{
+		Id: "x",
+		Address: testRemainNested{
+			Street: "Main",
+			Rest:   map[string]*dynamodb.AttributeValue{"zip": {N: aws.String("1")}},
+		},
+		Rest: map[string]*dynamodb.AttributeValue{"owner": {S: aws.String("billing")}},
+	}
+	if !reflect.DeepEqual(&data, want) {
+		t.Errorf("Unmarshal() got = %v, want %v", data, want)
+	}
+	got, err := me.Marshal(&data)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, item) {
+		t.Errorf("Marshal() got = %v, want %v", got, item)
+	}
+	data.Rest["id"] = &dynamodb.AttributeValue{S: aws.String("stale")}
+	if got, err := me.MarshalTagFilter(&data, IsKeyField); err != nil {
+		t.Fatalf("MarshalTagFilter() error = %v", err)
+	} else if want := map[string]*dynamodb.AttributeValue{"id": {S: aws.String("x")}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MarshalTagFilter() got = %v, want %v", got, want)
+	}
+	if err := me.Unmarshal(&data, map[string]*dynamodb.AttributeValue{"id": {S: aws.String("y")}}); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if data.Rest != nil {
+		t.Errorf("Unmarshal() remain is not reset: %v", data.Rest)
+	}
+}
+
+func TestDdbMarshaller_RemainErrors(t *testing.T) {
+	for _, target := range []interface{}{&testRemainWrongType{}, &testRemainTwice{}} {
+		if err := NewMarshaller().Unmarshal(target, map[string]*dynamodb.AttributeValue{}); err == nil {
+			t.Errorf("Unmarshal() error expected for %T", target)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package ddbmarshal
+
+import (
+	"reflect"
+	"sync"
+)
+
+// typeHooks are the special encodings the type may have, besides the one of its kind;
+// they are resolved once per type, so (un)marshalling of the values skips the interface checks not applicable to the type
+type typeHooks uint8
+
+const (
+	hookOptional        typeHooks = 1 << iota // Optional
+	hookMarshaler                             // DdbAttributeMarshaler with value or pointer receiver
+	hookUnmarshaler                           // DdbAttributeUnmarshaler
+	hookTextMarshaler                         // encoding.TextMarshaler or encoding.BinaryMarshaler with value or pointer receiver
+	hookTextUnmarshaler                       // encoding.TextUnmarshaler or encoding.BinaryUnmarshaler
+	hookStdlib                                // curated standard library types, see hasStdlibEncoding
+	hookBigNumber                             // Number and math/big types
+)
+
+// hooksCache keeps typeHooks per reflect.Type, the hooks depend on the type only, so the cache is shared by the marshallers
+var hooksCache sync.Map
+
+func hooksOf(typ reflect.Type) typeHooks {
+	if hooks, ok := hooksCache.Load(typ); ok {
+		return hooks.(typeHooks)
+	}
+	hooks := resolveHooks(typ)
+	hooksCache.Store(typ, hooks)
+	return hooks
+}
+
+func resolveHooks(typ reflect.Type) typeHooks {
+	var hooks typeHooks
+	if typ.Kind() == reflect.Interface {
+		return hooks
+	}
+	ptr := reflect.PtrTo(typ)
+	implements := func(iface reflect.Type) bool {
+		return typ.Implements(iface) || ptr.Implements(iface)
+	}
+	if typ.Kind() == reflect.Struct && typ.Implements(optionalSourceType) && ptr.Implements(optionalTargetType) {
+		hooks |= hookOptional
+	}
+	if implements(attributeMarshalerType) {
+		hooks |= hookMarshaler
+	}
+	if ptr.Implements(attributeUnmarshalerType) {
+		hooks |= hookUnmarshaler
+	}
+	if implements(textMarshalerType) || implements(binaryMarshalerType) {
+		hooks |= hookTextMarshaler
+	}
+	if ptr.Implements(textUnmarshalerType) || ptr.Implements(binaryUnmarshalerType) {
+		hooks |= hookTextUnmarshaler
+	}
+	switch typ {
+	case durationType, urlType, ipType, locationType, locationPointerType:
+		hooks |= hookStdlib
+	case numberType, bigIntType, bigFloatType, bigRatType:
+		hooks |= hookBigNumber
+	}
+	return hooks
+}
+
+func (hooks typeHooks) has(hook typeHooks) bool {
+	return hooks&hook != 0
+}
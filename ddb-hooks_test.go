@@ -0,0 +1,34 @@
+package ddbmarshal
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_hooksOf(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  reflect.Type
+		want typeHooks
+	}{
+		{"plain string", reflect.TypeOf(""), 0},
+		{"empty interface", interfaceType, 0},
+		{"optional", reflect.TypeOf(Optional[int]{}), hookOptional},
+		{"value receiver marshaler", reflect.TypeOf(testMoney{}), hookMarshaler | hookUnmarshaler},
+		{"pointer receiver marshaler", reflect.TypeOf(testPoint{}), hookMarshaler | hookUnmarshaler},
+		{"text and binary", reflect.TypeOf(testToken{}), hookTextMarshaler | hookTextUnmarshaler},
+		{"stdlib", reflect.TypeOf(time.Duration(0)), hookStdlib},
+		{"number", numberType, hookBigNumber},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hooksOf(tt.typ); got != tt.want {
+				t.Errorf("hooksOf(%v) = %b, want %b", tt.typ, got, tt.want)
+			}
+			if got := hooksOf(tt.typ); got != tt.want {
+				t.Errorf("cached hooksOf(%v) = %b, want %b", tt.typ, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,13 +1,14 @@
 package ddbmarshal
 
 import (
+	"encoding"
 	"errors"
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"math"
 	"reflect"
 	"strconv"
-	"strings"
 	"time"
 )
 
@@ -33,144 +34,252 @@ func IsKeyField(spec specs) bool {
 	return spec.isHashKey || spec.isRangeKey
 }
 
+// MarshalTagFilter marshals the fields accepted by the filter, their validation rules are checked first
 func (me *DdbMarshaller) MarshalTagFilter(source interface{}, filter func(spec specs) bool) (result map[string]*dynamodb.AttributeValue, err error) {
 	sourceValue, err := getValidMarshallingTargetValue(source)
 	if err != nil {
 		return nil, err
 	}
+	var violations ValidationErrors
+	if err := me.validateStruct(sourceValue, me.addPrefixToTheFieldNames, filter, "", "", &violations); err != nil {
+		return nil, err
+	} else if len(violations) > 0 {
+		return nil, violations
+	}
+	return me.marshalStruct(sourceValue, me.addPrefixToTheFieldNames, filter)
+}
+
+func acceptAllFields(specs) bool {
+	return true
+}
+
+// marshalStruct converts fields of the struct value to the attributes,
+// it is used for the top level item as well as for the nested structs;
+// attributes of the "remain" field are merged back, the fields take precedence over them
+func (me *DdbMarshaller) marshalStruct(sourceValue reflect.Value, prefix string, filter func(spec specs) bool) (result map[string]*dynamodb.AttributeValue, err error) {
+	fields, err := me.structFields(sourceValue.Type(), prefix)
+	if err != nil {
+		return nil, err
+	}
 	result = make(map[string]*dynamodb.AttributeValue)
-	for i, I := 0, sourceValue.NumField(); i < I; i++ {
-		fieldType := sourceValue.Type().Field(i)
-		checkUntagged := me.marshalAllPublicFields && fieldType.IsExported()
-		if ddbSpecStr, ok := fieldType.Tag.Lookup(TagDdb); ok || checkUntagged {
-			// TODO: refactor me
-			// TODO: add unit tests on marshaller flags
-			if !fieldType.IsExported() {
-				return nil, errors.New("can't use ddb field for unexported fieldType " + fieldType.Name)
-			}
-			var ddbSpecs specs
-			if !ok {
-				if checkUntagged {
-					ddbSpecs = specs{name: fieldType.Name}
-					if me.decapitalizeUntaggedFields {
-						ddbSpecs.name = strings.ToLower(ddbSpecs.name[0:1]) + ddbSpecs.name[1:]
-					}
-				} else {
-					continue
-				}
-			} else if ddbSpecs, err = ParseDdbTag(ddbSpecStr); err != nil {
+	var remain reflect.Value
+	for _, field := range fields {
+		ddbSpecs := field.spec
+		if !filter(ddbSpecs) {
+			continue
+		}
+		fieldValue, ok := fieldForRead(sourceValue, field.index)
+		if ok && field.defaultAttr != nil && me.marshalDefaults && fieldValue.IsZero() {
+			if attr, err := me.marshalDefault(field, fieldValue.Type()); err != nil {
 				return nil, err
+			} else if attr != nil {
+				result[ddbSpecs.name] = attr
 			}
-			ddbSpecs.name = me.addPrefixToTheFieldNames + ddbSpecs.name
-			if filter(ddbSpecs) {
-				fieldValue := sourceValue.Field(i)
-				if result[ddbSpecs.name], err = ddbBasicMarshal(fieldValue); err != nil {
-					return nil, err
-				}
+			continue
+		}
+		if !ok || ddbSpecs.omitEmpty && isEmptyValue(fieldValue) {
+			continue
+		}
+		if ddbSpecs.remain {
+			remain = fieldValue
+			continue
+		}
+		if attr, err := me.ddbBasicMarshal(fieldValue, ddbSpecs); err != nil {
+			return nil, err
+		} else if attr != nil {
+			result[ddbSpecs.name] = attr
+		} else if me.isNullable(fieldValue, ddbSpecs) {
+			result[ddbSpecs.name] = &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+		}
+	}
+	if remain.IsValid() {
+		for k, v := range remain.Interface().(map[string]*dynamodb.AttributeValue) {
+			if _, ok := result[k]; !ok && v != nil {
+				result[k] = v
 			}
 		}
 	}
 	return result, nil
 }
 
-func ddbBasicMarshal(value reflect.Value) (*dynamodb.AttributeValue, error) {
-	switch value := value.Interface().(type) {
-	case bool:
-		return &dynamodb.AttributeValue{BOOL: aws.Bool(value)}, nil
-	case string:
-		return &dynamodb.AttributeValue{S: aws.String(value)}, nil
-	case []string:
-		return &dynamodb.AttributeValue{SS: aws.StringSlice(value)}, nil
-	case []byte:
-		return &dynamodb.AttributeValue{B: value}, nil
-	case [][]byte:
-		return &dynamodb.AttributeValue{BS: value}, nil
-	case
-		map[string]string,
-		map[string]int,
-		map[string]uint,
-		map[string]int64,
-		map[string]uint64,
-		map[string]float32,
-		map[string]float64,
-		map[string]time.Time:
-		if theMap, err := ddbMarshalMap(value); err != nil {
-			return nil, err
-		} else {
-			return &dynamodb.AttributeValue{M: theMap}, nil
+// ddbBasicMarshal returns nil attribute (and no error) when there is nothing to store, i.e. for nil pointer.
+// Registered converters and DdbAttributeMarshaler implementations take precedence, otherwise the value is dispatched on its kind,
+// so named types are handled the same way as their underlying types; encoding.TextMarshaler and
+// encoding.BinaryMarshaler are used for the types which are not numbers, booleans or strings
+func (me *DdbMarshaller) ddbBasicMarshal(value reflect.Value, spec specs) (*dynamodb.AttributeValue, error) {
+	hooks := hooksOf(value.Type())
+	if hooks.has(hookOptional) {
+		if attr, ok, err := me.marshalOptional(value, spec); ok {
+			return attr, err
 		}
-	case int, int64, uint, uint64, float32, float64, time.Time:
+	}
+	if attr, ok, err := me.marshalConverted(value); ok {
+		return attr, err
+	}
+	if hooks.has(hookMarshaler) {
+		if attr, ok, err := marshalCustom(value); ok {
+			return attr, err
+		}
+	}
+	if hooks.has(hookStdlib) {
+		if attr, ok, err := marshalStdlib(value, spec); ok {
+			return attr, err
+		}
+	}
+	if hooks.has(hookBigNumber) {
+		if attr, ok, err := marshalBigNumber(value); ok {
+			return attr, err
+		}
+	}
+	switch value.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if value.IsNil() {
+			return nil, nil
+		}
+		return me.ddbBasicMarshal(value.Elem(), spec)
+	}
+	if (spec.asText || spec.asBinary) && hooks.has(hookTextMarshaler) {
+		if attr, ok, err := marshalTextOrBinary(value, spec); ok {
+			return attr, err
+		}
+	}
+	if value.Type() == timeType {
+		return me.marshalTime(value.Interface().(time.Time), spec), nil
+	}
+	if isNumberType(value.Type()) {
 		if str, err := ddbFormatNum(value); err != nil {
 			return nil, err
 		} else {
 			return &dynamodb.AttributeValue{N: aws.String(str)}, nil
 		}
-	case []int, []int64, []uint, []uint64, []float32, []float64, []time.Time:
-		if strs, err := ddbFormatNums(value); err != nil {
+	}
+	switch value.Kind() {
+	case reflect.Bool:
+		return &dynamodb.AttributeValue{BOOL: aws.Bool(value.Bool())}, nil
+	case reflect.String:
+		return &dynamodb.AttributeValue{S: aws.String(value.String())}, nil
+	}
+	if hooks.has(hookTextMarshaler) {
+		if attr, ok, err := marshalTextOrBinary(value, spec); ok {
+			return attr, err
+		}
+	}
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return marshalBytes(value, spec)
+		}
+		if me.sliceAsSet(value.Type().Elem(), spec) {
+			return me.marshalSet(value, spec)
+		}
+		if theList, err := me.ddbMarshalList(value, spec); err != nil {
 			return nil, err
 		} else {
-			return &dynamodb.AttributeValue{NS: aws.StringSlice(strs)}, nil
+			return &dynamodb.AttributeValue{L: theList}, nil
+		}
+	case reflect.Map:
+		if isSetMapType(value.Type()) {
+			return me.marshalMapSet(value, spec)
+		}
+		if theMap, err := me.ddbMarshalMap(value, spec); err != nil {
+			return nil, err
+		} else {
+			return &dynamodb.AttributeValue{M: theMap}, nil
+		}
+	case reflect.Struct:
+		if theMap, err := me.marshalStruct(value, "", acceptAllFields); err != nil {
+			return nil, err
+		} else {
+			return &dynamodb.AttributeValue{M: theMap}, nil
 		}
-	default:
-		return nil, errors.New(fmt.Sprintf("Can't format type %t", value))
 	}
+	return nil, errors.New(fmt.Sprintf("Can't format type %v", value.Type()))
 }
 
-func ddbFormatNums(value interface{}) ([]string, error) {
-	result := make([]string, 0)
-	switch reflect.TypeOf(value).Kind() {
-	case reflect.Slice:
-		slice := reflect.ValueOf(value)
-
-		for i := 0; i < slice.Len(); i++ {
-			if str, err := ddbFormatNum(slice.Index(i).Interface()); err != nil {
-				return nil, err
-			} else {
-				result = append(result, str)
-			}
+func ddbFormatNum(value reflect.Value) (string, error) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(value.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		if val := value.Float(); math.IsNaN(val) || math.IsInf(val, 0) {
+			return "", errors.New(fmt.Sprintf("can't store %v as a number", val))
 		}
+		return strconv.FormatFloat(value.Float(), 'G', -1, value.Type().Bits()), nil
 	default:
-		return nil, errors.New(fmt.Sprintf("slice of numbers is expected, got %t", value))
+		return "", errors.New(fmt.Sprintf("Unsupported number type: %v", value.Type()))
 	}
-	return result, nil
 }
 
-func ddbFormatNum(val interface{}) (string, error) {
-	switch val := val.(type) {
-	case int:
-		return strconv.Itoa(val), nil
-	case int64:
-		return strconv.FormatInt(val, 10), nil
-	case uint:
-		return strconv.FormatUint(uint64(val), 10), nil
-	case uint64:
-		return strconv.FormatUint(val, 10), nil
-	case float32:
-		return strconv.FormatFloat(float64(val), 'G', -1, 64), nil
-	case float64:
-		return strconv.FormatFloat(val, 'G', -1, 64), nil
-	case time.Time:
-		return strconv.FormatInt(val.Unix(), 10), nil
-	default:
-		return "", errors.New(fmt.Sprintf("Unsupported number type: %t", val))
+func (me *DdbMarshaller) ddbMarshalMap(value reflect.Value, spec specs) (result map[string]*dynamodb.AttributeValue, err error) {
+	if !isMapKeyType(value.Type().Key()) {
+		return nil, errors.New(fmt.Sprintf("map key should be string, integer or encoding.TextMarshaler, got %v", value.Type()))
+	}
+	result = make(map[string]*dynamodb.AttributeValue)
+	iter := value.MapRange()
+	for iter.Next() {
+		k, err := formatMapKey(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		v := iter.Value()
+		if attr, err := me.ddbBasicMarshal(v, spec); err != nil {
+			return nil, err
+		} else if attr != nil {
+			result[k] = attr
+		} else if me.isNullable(v, spec) {
+			result[k] = &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+		}
 	}
+	return
 }
 
-func ddbMarshalMap(value interface{}) (result map[string]*dynamodb.AttributeValue, err error) {
-	switch reflect.TypeOf(value).Kind() {
-	case reflect.Map:
-		result = make(map[string]*dynamodb.AttributeValue)
-		valueMap := reflect.ValueOf(value)
-		iter := valueMap.MapRange()
-		for iter.Next() {
-			k := iter.Key()
-			v := iter.Value()
-			if result[k.String()], err = ddbBasicMarshal(v); err != nil {
-				return nil, err
-			}
+// isMapKeyType reports the types usable as keys of M attribute: strings, integers and encoding.TextMarshaler
+func isMapKeyType(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return typ.Implements(textMarshalerType) || reflect.PtrTo(typ).Implements(textMarshalerType)
+}
+
+// formatMapKey checks string kind first, then encoding.TextMarshaler, then integers, same as parseMapKey and encoding/json do
+func formatMapKey(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+	if marshaler, ok := asInterface(key, textMarshalerType).(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		return string(text), err
+	}
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	}
+	return "", errors.New(fmt.Sprintf("unsupported map key type %v", key.Type()))
+}
+
+// ddbMarshalList keeps order and duplicates of the slice elements, absent elements are stored as NULL;
+// slices nested into the list are lists too, unless "set" tag option is given
+func (me *DdbMarshaller) ddbMarshalList(value reflect.Value, spec specs) ([]*dynamodb.AttributeValue, error) {
+	elemSpec := spec
+	if !spec.asSet {
+		elemSpec.asList = true
+	}
+	result := make([]*dynamodb.AttributeValue, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		if attr, err := me.ddbBasicMarshal(value.Index(i), elemSpec); err != nil {
+			return nil, err
+		} else if attr == nil {
+			result = append(result, &dynamodb.AttributeValue{NULL: aws.Bool(true)})
+		} else {
+			result = append(result, attr)
 		}
-	default:
-		return nil, errors.New(fmt.Sprintf("map[string] is expected, got %t", value))
 	}
-	return
+	return result, nil
 }
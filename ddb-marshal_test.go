@@ -1,6 +1,7 @@
 package ddbmarshal
 
 import (
+	"errors"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"log"
@@ -27,6 +28,278 @@ type testDdbMarshal struct {
 	Expire     time.Time          `ddb:"expire"`
 }
 
+type testAddress struct {
+	Street string            `ddb:"street"`
+	Zip    int               `ddb:"zip"`
+	Geo    *testGeoPoint     `ddb:"geo"`
+	Tags   map[string]string `ddb:"tags"`
+}
+
+type testGeoPoint struct {
+	Lat float64 `ddb:"lat"`
+	Lon float64 `ddb:"lon"`
+}
+
+type testNested struct {
+	Name    string       `ddb:"name"`
+	Home    testAddress  `ddb:"home"`
+	Work    *testAddress `ddb:"work"`
+	Billing *testAddress `ddb:"billing"`
+}
+
+func prepareNestedStruct() *testNested {
+	return &testNested{
+		Name: "nested",
+		Home: testAddress{
+			Street: "Main st",
+			Zip:    12345,
+			Geo:    &testGeoPoint{Lat: 1.5, Lon: -2.5},
+		},
+		Work: &testAddress{
+			Street: "Side st",
+			Zip:    54321,
+			Tags:   map[string]string{"floor": "3"},
+		},
+	}
+}
+
+func prepareNestedDdb() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"name": {S: aws.String("nested")},
+		"home": {M: map[string]*dynamodb.AttributeValue{
+			"street": {S: aws.String("Main st")},
+			"zip":    {N: aws.String("12345")},
+			"geo": {M: map[string]*dynamodb.AttributeValue{
+				"lat": {N: aws.String("1.5")},
+				"lon": {N: aws.String("-2.5")},
+			}},
+			"tags": {M: map[string]*dynamodb.AttributeValue{}},
+		}},
+		"work": {M: map[string]*dynamodb.AttributeValue{
+			"street": {S: aws.String("Side st")},
+			"zip":    {N: aws.String("54321")},
+			"tags": {M: map[string]*dynamodb.AttributeValue{
+				"floor": {S: aws.String("3")},
+			}},
+		}},
+	}
+}
+
+type testLineItem struct {
+	Sku string `ddb:"sku"`
+	Qty int    `ddb:"qty"`
+}
+
+type testLists struct {
+	Items  []testLineItem  `ddb:"items"`
+	Events []*testGeoPoint `ddb:"events"`
+	Mixed  []interface{}   `ddb:"mixed"`
+	Groups [][]string      `ddb:"groups"`
+	Sets   [][]string      `ddb:"sets,set"`
+}
+
+func prepareListsStruct() *testLists {
+	return &testLists{
+		Items: []testLineItem{
+			{Sku: "a", Qty: 1},
+			{Sku: "a", Qty: 1},
+			{Sku: "b", Qty: 2},
+		},
+		Events: []*testGeoPoint{{Lat: 1, Lon: 2}, nil},
+		Mixed: []interface{}{"x", 1.5, true, nil, []interface{}{"y"},
+			map[string]interface{}{"k": "v"}},
+		Groups: [][]string{{"b", "a", "a"}, {"c"}},
+		Sets:   [][]string{{"a", "b"}},
+	}
+}
+
+func prepareListsDdb() map[string]*dynamodb.AttributeValue {
+	item := func(sku, qty string) *dynamodb.AttributeValue {
+		return &dynamodb.AttributeValue{M: map[string]*dynamodb.AttributeValue{
+			"sku": {S: aws.String(sku)},
+			"qty": {N: aws.String(qty)},
+		}}
+	}
+	return map[string]*dynamodb.AttributeValue{
+		"items": {L: []*dynamodb.AttributeValue{item("a", "1"), item("a", "1"), item("b", "2")}},
+		"events": {L: []*dynamodb.AttributeValue{
+			{M: map[string]*dynamodb.AttributeValue{
+				"lat": {N: aws.String("1")},
+				"lon": {N: aws.String("2")},
+			}},
+			{NULL: aws.Bool(true)},
+		}},
+		"mixed": {L: []*dynamodb.AttributeValue{
+			{S: aws.String("x")},
+			{N: aws.String("1.5")},
+			{BOOL: aws.Bool(true)},
+			{NULL: aws.Bool(true)},
+			{L: []*dynamodb.AttributeValue{{S: aws.String("y")}}},
+			{M: map[string]*dynamodb.AttributeValue{"k": {S: aws.String("v")}}},
+		}},
+		"groups": {L: []*dynamodb.AttributeValue{
+			{L: []*dynamodb.AttributeValue{{S: aws.String("b")}, {S: aws.String("a")}, {S: aws.String("a")}}},
+			{L: []*dynamodb.AttributeValue{{S: aws.String("c")}}},
+		}},
+		"sets": {L: []*dynamodb.AttributeValue{
+			{SS: aws.StringSlice([]string{"a", "b"})},
+		}},
+	}
+}
+
+type testPointers struct {
+	Name    *string    `ddb:"name"`
+	Count   *int64     `ddb:"count"`
+	Expire  *time.Time `ddb:"expire"`
+	Missing *string    `ddb:"missing"`
+}
+
+func preparePointersStruct() *testPointers {
+	name := "a Name"
+	count := int64(42)
+	expire := mustParseTime(THE_TIME)
+	return &testPointers{Name: &name, Count: &count, Expire: &expire}
+}
+
+func preparePointersDdb() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"name":   {S: aws.String("a Name")},
+		"count":  {N: aws.String("42")},
+		"expire": {N: aws.String(strconv.FormatInt(mustParseTime(THE_TIME).Unix(), 10))},
+	}
+}
+
+type testOmitEmpty struct {
+	Name    string            `ddb:"name,omitempty"`
+	Count   int               `ddb:"count,omitempty"`
+	Groups  []string          `ddb:"groups,omitempty"`
+	Props   map[string]string `ddb:"props,omitempty"`
+	Expire  time.Time         `ddb:"expire,omitempty"`
+	Kept    string            `ddb:"kept"`
+	Unnamed string            `ddb:",omitempty"`
+}
+
+type testStatus string
+type testCents int64
+type testTags []string
+
+type testKinds struct {
+	I8     int8                 `ddb:"i8"`
+	I16    int16                `ddb:"i16"`
+	I32    int32                `ddb:"i32"`
+	U8     uint8                `ddb:"u8"`
+	U16    uint16               `ddb:"u16"`
+	U32    uint32               `ddb:"u32"`
+	Status testStatus           `ddb:"status"`
+	Price  testCents            `ddb:"price"`
+	Tags   testTags             `ddb:"tags"`
+	Small  []int16              `ddb:"small"`
+	Prices map[string]testCents `ddb:"prices"`
+	States map[string]bool      `ddb:"states"`
+}
+
+func prepareKindsStruct() *testKinds {
+	return &testKinds{
+		I8:     -8,
+		I16:    -16,
+		I32:    -32,
+		U8:     8,
+		U16:    16,
+		U32:    32,
+		Status: "active",
+		Price:  1999,
+		Tags:   testTags{"x", "y"},
+		Small:  []int16{1, -1},
+		Prices: map[string]testCents{"base": 100},
+		States: map[string]bool{"on": true},
+	}
+}
+
+func prepareKindsDdb() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"i8":     {N: aws.String("-8")},
+		"i16":    {N: aws.String("-16")},
+		"i32":    {N: aws.String("-32")},
+		"u8":     {N: aws.String("8")},
+		"u16":    {N: aws.String("16")},
+		"u32":    {N: aws.String("32")},
+		"status": {S: aws.String("active")},
+		"price":  {N: aws.String("1999")},
+		"tags":   {SS: aws.StringSlice([]string{"x", "y"})},
+		"small":  {NS: aws.StringSlice([]string{"1", "-1"})},
+		"prices": {M: map[string]*dynamodb.AttributeValue{"base": {N: aws.String("100")}}},
+		"states": {M: map[string]*dynamodb.AttributeValue{"on": {BOOL: aws.Bool(true)}}},
+	}
+}
+
+type testRegion string
+
+// testZone is an integer key with text encoding, the text takes precedence over the number
+type testZone int
+
+var testZoneNames = []string{"eu", "us"}
+
+func (z testZone) MarshalText() ([]byte, error) {
+	return []byte(testZoneNames[z]), nil
+}
+
+func (z *testZone) UnmarshalText(text []byte) error {
+	for i, name := range testZoneNames {
+		if name == string(text) {
+			*z = testZone(i)
+			return nil
+		}
+	}
+	return errors.New("bad zone " + string(text))
+}
+
+type testQuotaConfig struct {
+	Limit   int  `ddb:"limit"`
+	Enabled bool `ddb:"enabled"`
+}
+
+type testMaps struct {
+	ByShard  map[int64][]string             `ddb:"byShard"`
+	ByRegion map[testRegion]testQuotaConfig `ddb:"byRegion"`
+	ByToken  map[testToken]bool             `ddb:"byToken"`
+	ByZone   map[testZone]int               `ddb:"byZone"`
+	ByByte   map[uint8][]byte               `ddb:"byByte"`
+	Nested   map[string]map[int]testCents   `ddb:"nested"`
+	Lists    map[string][]testLineItem      `ddb:"lists"`
+}
+
+func prepareMapsStruct() *testMaps {
+	return &testMaps{
+		ByShard:  map[int64][]string{-1: {"a"}},
+		ByRegion: map[testRegion]testQuotaConfig{"eu": {Limit: 10, Enabled: true}},
+		ByToken:  map[testToken]bool{{5}: true},
+		ByZone:   map[testZone]int{1: 5},
+		ByByte:   map[uint8][]byte{255: {1}},
+		Nested:   map[string]map[int]testCents{"x": {1: 100}},
+		Lists:    map[string][]testLineItem{"l": {{Sku: "s", Qty: 2}}},
+	}
+}
+
+func prepareMapsDdb() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"byShard": {M: map[string]*dynamodb.AttributeValue{"-1": {SS: aws.StringSlice([]string{"a"})}}},
+		"byRegion": {M: map[string]*dynamodb.AttributeValue{"eu": {M: map[string]*dynamodb.AttributeValue{
+			"limit":   {N: aws.String("10")},
+			"enabled": {BOOL: aws.Bool(true)},
+		}}}},
+		"byToken": {M: map[string]*dynamodb.AttributeValue{"tok-5": {BOOL: aws.Bool(true)}}},
+		"byZone":  {M: map[string]*dynamodb.AttributeValue{"us": {N: aws.String("5")}}},
+		"byByte":  {M: map[string]*dynamodb.AttributeValue{"255": {B: []byte{1}}}},
+		"nested": {M: map[string]*dynamodb.AttributeValue{"x": {M: map[string]*dynamodb.AttributeValue{
+			"1": {N: aws.String("100")},
+		}}}},
+		"lists": {M: map[string]*dynamodb.AttributeValue{"l": {L: []*dynamodb.AttributeValue{{M: map[string]*dynamodb.AttributeValue{
+			"sku": {S: aws.String("s")},
+			"qty": {N: aws.String("2")},
+		}}}}}},
+	}
+}
+
 const (
 	THE_TIME = "2022-02-02T22:02:20Z"
 )
@@ -118,6 +391,47 @@ func TestDdbMarshaller_Marshal(t *testing.T) {
 			wantResult: prepareDdb(),
 			wantErr:    false,
 		},
+		{
+			name:       "nested structs",
+			args:       args{prepareNestedStruct()},
+			wantResult: prepareNestedDdb(),
+			wantErr:    false,
+		},
+		{
+			name:       "lists",
+			args:       args{prepareListsStruct()},
+			wantResult: prepareListsDdb(),
+			wantErr:    false,
+		},
+		{
+			name:       "pointers",
+			args:       args{preparePointersStruct()},
+			wantResult: preparePointersDdb(),
+			wantErr:    false,
+		},
+		{
+			name:       "integer widths and named types",
+			args:       args{prepareKindsStruct()},
+			wantResult: prepareKindsDdb(),
+			wantErr:    false,
+		},
+		{
+			name: "unsupported type",
+			args: args{&struct {
+				C chan int `ddb:"c"`
+			}{}},
+			wantResult: nil,
+			wantErr:    true,
+		},
+		{
+			name: "omitempty",
+			args: args{&testOmitEmpty{Unnamed: "named by field"}},
+			wantResult: map[string]*dynamodb.AttributeValue{
+				"kept":    {S: aws.String("")},
+				"Unnamed": {S: aws.String("named by field")},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -133,3 +447,22 @@ func TestDdbMarshaller_Marshal(t *testing.T) {
 		})
 	}
 }
+
+func TestDdbMarshaller_SetMarshalNilAsNull(t *testing.T) {
+	me := NewMarshaller()
+	me.SetMarshalNilAsNull(true)
+	gotResult, err := me.Marshal(&testPointers{})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	null := &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+	wantResult := map[string]*dynamodb.AttributeValue{
+		"name":    null,
+		"count":   null,
+		"expire":  null,
+		"missing": null,
+	}
+	if !reflect.DeepEqual(gotResult, wantResult) {
+		t.Errorf("Marshal() gotResult = %v, want %v", gotResult, wantResult)
+	}
+}
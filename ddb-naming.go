@@ -0,0 +1,94 @@
+package ddbmarshal
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy converts go field name to the attribute name, it is used for the untagged fields
+// (see SetMarshalAllPublicFields) and for the fields with empty name in the tag
+type NamingStrategy func(fieldName string) string
+
+// SetNamingStrategy sets the strategy used for the attribute names of all the structs, including nested ones,
+// by Marshal, Unmarshal and GetUnmarshaledFields; it takes precedence over SetDecapitalizeUntaggedFieldNames,
+// nil restores the default: the field name as is
+func (marshaller *DdbMarshaller) SetNamingStrategy(strategy NamingStrategy) {
+	marshaller.namingStrategy = strategy
+	marshaller.resetFieldCache()
+}
+
+// Identity keeps the field name as is: HTTPStatus
+func Identity(fieldName string) string {
+	return fieldName
+}
+
+// SnakeCase converts the field name to snake_case: HTTPStatus to http_status, UserID to user_id
+func SnakeCase(fieldName string) string {
+	return strings.ToLower(strings.Join(splitWords(fieldName), "_"))
+}
+
+// ScreamingSnakeCase converts the field name to SCREAMING_SNAKE_CASE: HTTPStatus to HTTP_STATUS
+func ScreamingSnakeCase(fieldName string) string {
+	return strings.ToUpper(strings.Join(splitWords(fieldName), "_"))
+}
+
+// KebabCase converts the field name to kebab-case: HTTPStatus to http-status
+func KebabCase(fieldName string) string {
+	return strings.ToLower(strings.Join(splitWords(fieldName), "-"))
+}
+
+// CamelCase converts the field name to camelCase treating acronyms as words: HTTPStatus to httpStatus, UserID to userId
+func CamelCase(fieldName string) string {
+	words := splitWords(fieldName)
+	for i, word := range words {
+		word = strings.ToLower(word)
+		if i > 0 {
+			runes := []rune(word)
+			runes[0] = unicode.ToUpper(runes[0])
+			word = string(runes)
+		}
+		words[i] = word
+	}
+	return strings.Join(words, "")
+}
+
+// splitWords splits the field name into words on underscores, dashes and case changes,
+// acronyms are kept as words (HTTPStatus is HTTP and Status), including plural ones (UserIDs is User and IDs),
+// digits stay with the preceding word
+func splitWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if r == '_' || r == '-' {
+			if len(word) > 0 {
+				words, word = append(words, string(word)), nil
+			}
+			continue
+		}
+		if len(word) > 0 && unicode.IsUpper(r) {
+			prev := word[len(word)-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1]) && !isPluralSuffix(runes, i+1)
+			if !unicode.IsUpper(prev) || nextIsLower {
+				words, word = append(words, string(word)), nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+// isPluralSuffix reports "s" ending the acronym: at the end of the name, before a separator or the next word
+func isPluralSuffix(runes []rune, i int) bool {
+	if runes[i] != 's' {
+		return false
+	}
+	if i+1 == len(runes) {
+		return true
+	}
+	next := runes[i+1]
+	return unicode.IsUpper(next) || next == '_' || next == '-'
+}
@@ -0,0 +1,86 @@
+package ddbmarshal
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+	"testing"
+)
+
+func TestNamingStrategies(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy NamingStrategy
+		want     []string
+	}{
+		{"identity", Identity, []string{"HTTPStatus", "UserID", "ID", "Name", "Line2Text", "already_snake", "UserIDs", "URLs", "IDs", "IDsByName"}},
+		{"snake", SnakeCase, []string{"http_status", "user_id", "id", "name", "line2_text", "already_snake", "user_ids", "urls", "ids", "ids_by_name"}},
+		{"camel", CamelCase, []string{"httpStatus", "userId", "id", "name", "line2Text", "alreadySnake", "userIds", "urls", "ids", "idsByName"}},
+		{"kebab", KebabCase, []string{"http-status", "user-id", "id", "name", "line2-text", "already-snake", "user-ids", "urls", "ids", "ids-by-name"}},
+		{"screaming snake", ScreamingSnakeCase, []string{"HTTP_STATUS", "USER_ID", "ID", "NAME", "LINE2_TEXT", "ALREADY_SNAKE", "USER_IDS", "URLS", "IDS", "IDS_BY_NAME"}},
+	}
+	fieldNames := []string{"HTTPStatus", "UserID", "ID", "Name", "Line2Text", "already_snake", "UserIDs", "URLs", "IDs", "IDsByName"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, fieldName := range fieldNames {
+				if got := tt.strategy(fieldName); got != tt.want[i] {
+					t.Errorf("%s(%q) = %q, want %q", tt.name, fieldName, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+type testNamingInner struct {
+	ZipCode string
+	CityID  int `ddb:",omitempty"`
+}
+
+type testNaming struct {
+	HTTPStatus int
+	UserID     string          `ddb:""`
+	Address    testNamingInner // untagged nested struct
+	Explicit   string          `ddb:"Explicit_Name"`
+}
+
+func TestDdbMarshaller_SetNamingStrategy(t *testing.T) {
+	me := NewMarshaller()
+	me.SetMarshalAllPublicFields(true)
+	me.SetDecapitalizeUntaggedFieldNames(true)
+	source := &testNaming{HTTPStatus: 200, UserID: "u", Address: testNamingInner{ZipCode: "z", CityID: 7}, Explicit: "e"}
+	if got, err := me.Marshal(source); err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	} else if got["hTTPStatus"] == nil || got["UserID"] == nil {
+		t.Errorf("Marshal() got = %v, decapitalized untagged names expected without naming strategy", got)
+	}
+	me.SetNamingStrategy(SnakeCase)
+	item, err := me.Marshal(source)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := map[string]*dynamodb.AttributeValue{
+		"http_status": {N: aws.String("200")},
+		"user_id":     {S: aws.String("u")},
+		"address": {M: map[string]*dynamodb.AttributeValue{
+			"zip_code": {S: aws.String("z")},
+			"city_id":  {N: aws.String("7")},
+		}},
+		"Explicit_Name": {S: aws.String("e")},
+	}
+	if !reflect.DeepEqual(item, want) {
+		t.Errorf("Marshal() got = %v, want %v", item, want)
+	}
+	var data testNaming
+	if err := me.Unmarshal(&data, item); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(&data, source) {
+		t.Errorf("Unmarshal() got = %v, want %v", data, source)
+	}
+	item["extra_field"] = &dynamodb.AttributeValue{S: aws.String("x")}
+	if rest, err := me.GetUnmarshaledFields(&data, item); err != nil {
+		t.Fatalf("GetUnmarshaledFields() error = %v", err)
+	} else if want := map[string]*dynamodb.AttributeValue{"extra_field": item["extra_field"]}; !reflect.DeepEqual(rest, want) {
+		t.Errorf("GetUnmarshaledFields() got = %v, want %v", rest, want)
+	}
+}
@@ -0,0 +1,135 @@
+package ddbmarshal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+)
+
+// Number keeps the exact text of N attribute, for the values not fitting into go numeric types without loss
+type Number string
+
+func (n Number) String() string {
+	return string(n)
+}
+
+func (n Number) Int64() (int64, error) {
+	return strconv.ParseInt(string(n), 10, 64)
+}
+
+func (n Number) Float64() (float64, error) {
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// BigFloat parses the number with the precision enough for DynamoDB 38 digits
+func (n Number) BigFloat() (*big.Float, error) {
+	result, _, err := big.ParseFloat(string(n), 10, bigFloatPrecision, big.ToNearestEven)
+	return result, err
+}
+
+// bigFloatPrecision is used for decoding into big.Float without precision set, it covers 38 decimal digits
+const bigFloatPrecision = 128
+
+// ddbNumberPattern is the decimal number accepted by DynamoDB, no NaN, infinities or hex
+var ddbNumberPattern = regexp.MustCompile(`^-?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?$`)
+
+var (
+	numberType   = reflect.TypeOf(Number(""))
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+	bigRatType   = reflect.TypeOf(big.Rat{})
+)
+
+// marshalBigNumber reports false if the value is neither Number nor one of math/big types,
+// empty Number is not stored, same as nil pointer
+func marshalBigNumber(value reflect.Value) (*dynamodb.AttributeValue, bool, error) {
+	var str string
+	switch value.Type() {
+	case numberType:
+		str = value.String()
+		if str == "" {
+			return nil, true, nil
+		}
+		if !ddbNumberPattern.MatchString(str) {
+			return nil, true, errors.New(fmt.Sprintf("invalid number %q", str))
+		}
+	case bigIntType:
+		str = addressOf(value).Interface().(*big.Int).String()
+	case bigFloatType:
+		f := addressOf(value).Interface().(*big.Float)
+		if f.IsInf() {
+			return nil, true, errors.New(fmt.Sprintf("can't store infinite number %v", f))
+		}
+		str = f.Text('g', -1)
+	case bigRatType:
+		var err error
+		if str, err = formatRat(addressOf(value).Interface().(*big.Rat)); err != nil {
+			return nil, true, err
+		}
+	default:
+		return nil, false, nil
+	}
+	return &dynamodb.AttributeValue{N: aws.String(str)}, true, nil
+}
+
+// formatRat returns exact decimal representation, rationals with infinite decimal expansion (like 1/3) are rejected
+func formatRat(r *big.Rat) (string, error) {
+	denom := new(big.Int).Set(r.Denom())
+	twos, fives := 0, 0
+	two, five, mod := big.NewInt(2), big.NewInt(5), new(big.Int)
+	for mod.Mod(denom, two).Sign() == 0 {
+		denom.Quo(denom, two)
+		twos++
+	}
+	for mod.Mod(denom, five).Sign() == 0 {
+		denom.Quo(denom, five)
+		fives++
+	}
+	if denom.Cmp(big.NewInt(1)) != 0 {
+		return "", errors.New(fmt.Sprintf("%v has no exact decimal representation", r))
+	}
+	digits := twos
+	if fives > digits {
+		digits = fives
+	}
+	return r.FloatString(digits), nil
+}
+
+// unmarshalBigNumber reports false if the value is neither Number nor one of math/big types
+func unmarshalBigNumber(value reflect.Value, attr *dynamodb.AttributeValue) (bool, error) {
+	switch value.Type() {
+	case numberType, bigIntType, bigFloatType, bigRatType:
+	default:
+		return false, nil
+	}
+	if attr.N == nil {
+		return true, typeMismatch(value.Type(), attr, "N")
+	}
+	str := *attr.N
+	switch value.Type() {
+	case numberType:
+		value.SetString(str)
+	case bigIntType:
+		if _, ok := value.Addr().Interface().(*big.Int).SetString(str, 10); !ok {
+			return true, invalidValue(errors.New(fmt.Sprintf("invalid integer %q", str)))
+		}
+	case bigFloatType:
+		f := value.Addr().Interface().(*big.Float)
+		if f.Prec() == 0 {
+			f.SetPrec(bigFloatPrecision)
+		}
+		if _, _, err := f.Parse(str, 10); err != nil {
+			return true, invalidValue(err)
+		}
+	case bigRatType:
+		if _, ok := value.Addr().Interface().(*big.Rat).SetString(str); !ok {
+			return true, invalidValue(errors.New(fmt.Sprintf("invalid number %q", str)))
+		}
+	}
+	return true, nil
+}
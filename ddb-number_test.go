@@ -0,0 +1,136 @@
+package ddbmarshal
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+type testBigNumbers struct {
+	Int    *big.Int   `ddb:"int"`
+	Float  *big.Float `ddb:"float"`
+	Rat    *big.Rat   `ddb:"rat"`
+	Exact  Number     `ddb:"exact"`
+	Exacts []Number   `ddb:"exacts"`
+	Small  float32    `ddb:"small"`
+	Smalls []float32  `ddb:"smalls"`
+}
+
+func prepareBigNumbersStruct() *testBigNumbers {
+	i, _ := new(big.Int).SetString("12345678901234567890123456789012345678", 10)
+	f, _, _ := big.ParseFloat("1.2345678901234567890123456789", 10, bigFloatPrecision, big.ToNearestEven)
+	return &testBigNumbers{
+		Int:    i,
+		Float:  f,
+		Rat:    big.NewRat(5, 4),
+		Exact:  "0.10000000000000000000000000000000000001",
+		Exacts: []Number{"1", "1e100"},
+		Small:  0.1,
+		Smalls: []float32{0.3},
+	}
+}
+
+func prepareBigNumbersDdb() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"int":    {N: aws.String("12345678901234567890123456789012345678")},
+		"float":  {N: aws.String("1.2345678901234567890123456789")},
+		"rat":    {N: aws.String("1.25")},
+		"exact":  {N: aws.String("0.10000000000000000000000000000000000001")},
+		"exacts": {NS: aws.StringSlice([]string{"1", "1e100"})},
+		"small":  {N: aws.String("0.1")},
+		"smalls": {NS: aws.StringSlice([]string{"0.3"})},
+	}
+}
+
+func TestDdbMarshaller_BigNumbers(t *testing.T) {
+	me := NewMarshaller()
+	got, err := me.Marshal(prepareBigNumbersStruct())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := prepareBigNumbersDdb(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Marshal() got = %v, want %v", got, want)
+	}
+	var data testBigNumbers
+	if err := me.Unmarshal(&data, got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := prepareBigNumbersStruct()
+	if data.Int.Cmp(want.Int) != 0 || data.Float.Cmp(want.Float) != 0 || data.Rat.Cmp(want.Rat) != 0 {
+		t.Errorf("Unmarshal() got = %v %v %v, want %v %v %v", data.Int, data.Float, data.Rat, want.Int, want.Float, want.Rat)
+	}
+	if data.Exact != want.Exact || !reflect.DeepEqual(data.Exacts, want.Exacts) {
+		t.Errorf("Unmarshal() got = %v %v, want %v %v", data.Exact, data.Exacts, want.Exact, want.Exacts)
+	}
+	if data.Small != want.Small || !reflect.DeepEqual(data.Smalls, want.Smalls) {
+		t.Errorf("Unmarshal() got = %v %v, want %v %v", data.Small, data.Smalls, want.Small, want.Smalls)
+	}
+}
+
+func TestDdbMarshaller_InvalidNumbers(t *testing.T) {
+	tests := []struct {
+		name   string
+		source interface{}
+	}{
+		{"NaN", &struct {
+			V float64 `ddb:"v"`
+		}{math.NaN()}},
+		{"infinity", &struct {
+			V float32 `ddb:"v"`
+		}{float32(math.Inf(-1))}},
+		{"infinite big float", &struct {
+			V *big.Float `ddb:"v"`
+		}{new(big.Float).SetInf(false)}},
+		{"infinite decimal expansion", &struct {
+			V *big.Rat `ddb:"v"`
+		}{big.NewRat(1, 3)}},
+		{"not a number", &struct {
+			V Number `ddb:"v"`
+		}{"twelve"}},
+		{"infinite number", &struct {
+			V Number `ddb:"v"`
+		}{"+Inf"}},
+		{"NaN number", &struct {
+			V Number `ddb:"v"`
+		}{"NaN"}},
+		{"hex number", &struct {
+			V Number `ddb:"v"`
+		}{"0x10"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewMarshaller().Marshal(tt.source); err == nil {
+				t.Errorf("Marshal() error expected")
+			}
+		})
+	}
+}
+
+func TestDdbMarshaller_EmptyNumber(t *testing.T) {
+	got, err := NewMarshaller().Marshal(&struct {
+		V Number `ddb:"v"`
+		W int    `ddb:"w"`
+	}{})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := map[string]*dynamodb.AttributeValue{"w": {N: aws.String("0")}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Marshal() got = %v, want %v", got, want)
+	}
+}
+
+func TestNumber(t *testing.T) {
+	n := Number("42")
+	if i, err := n.Int64(); err != nil || i != 42 {
+		t.Errorf("Int64() = %v, %v", i, err)
+	}
+	if f, err := n.Float64(); err != nil || f != 42 {
+		t.Errorf("Float64() = %v, %v", f, err)
+	}
+	if f, err := n.BigFloat(); err != nil || f.Cmp(big.NewFloat(42)) != 0 {
+		t.Errorf("BigFloat() = %v, %v", f, err)
+	}
+}
@@ -0,0 +1,134 @@
+package ddbmarshal
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+)
+
+type optionalState uint8
+
+const (
+	optionalUnset optionalState = iota
+	optionalNull
+	optionalValue
+)
+
+// Optional is a tri-state field: unset (attribute is absent), null (NULL attribute) or holding a value.
+// Zero value is unset
+type Optional[T any] struct {
+	value T
+	state optionalState
+}
+
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{value: value, state: optionalValue}
+}
+
+func Null[T any]() Optional[T] {
+	return Optional[T]{state: optionalNull}
+}
+
+// IsSet reports the value or null is set, i.e. the attribute is present
+func (o Optional[T]) IsSet() bool {
+	return o.state != optionalUnset
+}
+
+func (o Optional[T]) IsNull() bool {
+	return o.state == optionalNull
+}
+
+// Get returns the value and true if there is a value (neither unset nor null)
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.state == optionalValue
+}
+
+// ValueOr returns the value if there is one, the fallback otherwise
+func (o Optional[T]) ValueOr(fallback T) T {
+	if o.state == optionalValue {
+		return o.value
+	}
+	return fallback
+}
+
+func (o *Optional[T]) Set(value T) {
+	o.value, o.state = value, optionalValue
+}
+
+func (o *Optional[T]) SetNull() {
+	var zero T
+	o.value, o.state = zero, optionalNull
+}
+
+func (o *Optional[T]) Unset() {
+	var zero T
+	o.value, o.state = zero, optionalUnset
+}
+
+func (o Optional[T]) optional() (optionalState, reflect.Value) {
+	return o.state, reflect.ValueOf(&o.value).Elem()
+}
+
+func (o *Optional[T]) setOptional(state optionalState) reflect.Value {
+	var zero T
+	o.value, o.state = zero, state
+	return reflect.ValueOf(&o.value).Elem()
+}
+
+// optionalSource and optionalTarget are implemented by any Optional[T]
+type optionalSource interface {
+	optional() (optionalState, reflect.Value)
+}
+
+type optionalTarget interface {
+	setOptional(state optionalState) reflect.Value
+}
+
+var (
+	optionalSourceType = reflect.TypeOf((*optionalSource)(nil)).Elem()
+	optionalTargetType = reflect.TypeOf((*optionalTarget)(nil)).Elem()
+)
+
+// marshalOptional reports false if the value is not Optional
+func (me *DdbMarshaller) marshalOptional(value reflect.Value, spec specs) (*dynamodb.AttributeValue, bool, error) {
+	if value.Kind() != reflect.Struct || !value.Type().Implements(optionalSourceType) {
+		return nil, false, nil
+	}
+	switch state, inner := value.Interface().(optionalSource).optional(); state {
+	case optionalNull:
+		return &dynamodb.AttributeValue{NULL: aws.Bool(true)}, true, nil
+	case optionalValue:
+		attr, err := me.ddbBasicMarshal(inner, spec)
+		if attr == nil && err == nil {
+			// the value is set, but there is nothing to store, i.e. Some[*string](nil)
+			attr = &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+		}
+		return attr, true, err
+	default:
+		return nil, true, nil
+	}
+}
+
+// unmarshalOptional reports false if the value is not Optional
+func (me *DdbMarshaller) unmarshalOptional(value reflect.Value, attr *dynamodb.AttributeValue, spec specs) (bool, error) {
+	if value.Kind() != reflect.Struct || !value.CanAddr() || !value.Addr().Type().Implements(optionalTargetType) {
+		return false, nil
+	}
+	target := value.Addr().Interface().(optionalTarget)
+	if aws.BoolValue(attr.NULL) {
+		target.setOptional(optionalNull)
+		return true, nil
+	}
+	return true, me.unmarshalValue(target.setOptional(optionalValue), attr, spec)
+}
+
+// isNullable reports the nil value is to be stored as NULL, per marshaller or per field option
+func (me *DdbMarshaller) isNullable(value reflect.Value, spec specs) bool {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil() && (me.marshalNilAsNull || spec.nullable)
+	case reflect.Slice:
+		return value.IsNil() && isBytesType(value.Type()) && (me.marshalNilAsNull || spec.nullable)
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package ddbmarshal
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+	"testing"
+)
+
+type testOptional struct {
+	Nickname Optional[string]        `ddb:"nickname"`
+	Age      Optional[int]           `ddb:"age"`
+	Address  Optional[*testGeoPoint] `ddb:"address"`
+	Note     *string                 `ddb:"note,nullable"`
+	Comment  *string                 `ddb:"comment"`
+	Scores   []Optional[int]         `ddb:"scores"`
+}
+
+func TestDdbMarshaller_Optional(t *testing.T) {
+	null := &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+	tests := []struct {
+		name       string
+		source     *testOptional
+		wantResult map[string]*dynamodb.AttributeValue
+	}{
+		{
+			name:   "unset",
+			source: &testOptional{Scores: []Optional[int]{}},
+			wantResult: map[string]*dynamodb.AttributeValue{
+				"note":   null,
+				"scores": {L: []*dynamodb.AttributeValue{}},
+			},
+		},
+		{
+			name: "null",
+			source: &testOptional{
+				Nickname: Null[string](),
+				Age:      Null[int](),
+				Address:  Null[*testGeoPoint](),
+				Scores:   []Optional[int]{Null[int]()},
+			},
+			wantResult: map[string]*dynamodb.AttributeValue{
+				"nickname": null,
+				"age":      null,
+				"address":  null,
+				"note":     null,
+				"scores":   {L: []*dynamodb.AttributeValue{null}},
+			},
+		},
+		{
+			name: "values",
+			source: &testOptional{
+				Nickname: Some("nick"),
+				Age:      Some(0),
+				Address:  Some(&testGeoPoint{Lat: 1, Lon: 2}),
+				Note:     aws.String("note"),
+				Comment:  aws.String("comment"),
+				Scores:   []Optional[int]{Some(1), Null[int]()},
+			},
+			wantResult: map[string]*dynamodb.AttributeValue{
+				"nickname": {S: aws.String("nick")},
+				"age":      {N: aws.String("0")},
+				"address": {M: map[string]*dynamodb.AttributeValue{
+					"lat": {N: aws.String("1")},
+					"lon": {N: aws.String("2")},
+				}},
+				"note":    {S: aws.String("note")},
+				"comment": {S: aws.String("comment")},
+				"scores":  {L: []*dynamodb.AttributeValue{{N: aws.String("1")}, null}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			me := NewMarshaller()
+			gotResult, err := me.Marshal(tt.source)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(gotResult, tt.wantResult) {
+				t.Errorf("Marshal() gotResult = %v, want %v", gotResult, tt.wantResult)
+			}
+			var gotData testOptional
+			if err := me.Unmarshal(&gotData, gotResult); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(&gotData, tt.source) {
+				t.Errorf("Unmarshal() gotData = %v, want %v", gotData, tt.source)
+			}
+		})
+	}
+}
+
+func TestDdbMarshaller_OptionalNilValue(t *testing.T) {
+	me := NewMarshaller()
+	got, err := me.Marshal(&testOptional{Address: Some[*testGeoPoint](nil)})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := (&dynamodb.AttributeValue{NULL: aws.Bool(true)}); !reflect.DeepEqual(got["address"], want) {
+		t.Errorf("Marshal() got = %v, want %v", got["address"], want)
+	}
+	var data testOptional
+	if err := me.Unmarshal(&data, got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(data.Address, Null[*testGeoPoint]()) {
+		t.Errorf("Unmarshal() got = %v, want null", data.Address)
+	}
+}
+
+func TestOptional(t *testing.T) {
+	var o Optional[string]
+	if o.IsSet() || o.IsNull() || o.ValueOr("x") != "x" {
+		t.Errorf("zero Optional is expected to be unset, got %v", o)
+	}
+	o.SetNull()
+	if !o.IsSet() || !o.IsNull() {
+		t.Errorf("Optional is expected to be null, got %v", o)
+	}
+	o.Set("v")
+	if v, ok := o.Get(); !ok || v != "v" || o.IsNull() {
+		t.Errorf("Optional is expected to have value, got %v", o)
+	}
+	o.Unset()
+	if o.IsSet() {
+		t.Errorf("Optional is expected to be unset, got %v", o)
+	}
+}
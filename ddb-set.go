@@ -0,0 +1,244 @@
+package ddbmarshal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+	"sort"
+)
+
+// SetElement lists the types usable as Set members, string members are stored as binaries with "binary" tag option
+type SetElement interface {
+	~string |
+		~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// Set is stored as SS, NS or BS, same as any map[T]struct{}; empty set is not stored
+type Set[T SetElement] map[T]struct{}
+
+func NewSet[T SetElement](values ...T) Set[T] {
+	result := make(Set[T], len(values))
+	for _, v := range values {
+		result[v] = struct{}{}
+	}
+	return result
+}
+
+// Add allocates the set if it is nil
+func (s *Set[T]) Add(values ...T) {
+	if *s == nil {
+		*s = make(Set[T], len(values))
+	}
+	for _, v := range values {
+		(*s)[v] = struct{}{}
+	}
+}
+
+func (s Set[T]) Remove(values ...T) {
+	for _, v := range values {
+		delete(s, v)
+	}
+}
+
+func (s Set[T]) Has(value T) bool {
+	_, ok := s[value]
+	return ok
+}
+
+func (s Set[T]) Len() int {
+	return len(s)
+}
+
+// Values returns the members in no particular order
+func (s Set[T]) Values() []T {
+	result := make([]T, 0, len(s))
+	for v := range s {
+		result = append(result, v)
+	}
+	return result
+}
+
+// SetEncodeSlicesAsLists makes slices of strings, numbers and byte slices stored as L instead of SS, NS or BS
+// for the fields without "set" or "list" tag option
+func (marshaller *DdbMarshaller) SetEncodeSlicesAsLists(value bool) {
+	marshaller.encodeSlicesAsLists = value
+}
+
+// isSettableType reports element types natively stored as set members
+func isSettableType(typ reflect.Type) bool {
+	return isNumberType(typ) || typ == timeType || typ.Kind() == reflect.String || isBytesType(typ)
+}
+
+// sliceAsSet reports whether the slice is stored as a set, slices nested into the lists are lists
+// unless "set" tag option is given (see ddbMarshalList), and the slices of slices are never sets
+func (me *DdbMarshaller) sliceAsSet(elemType reflect.Type, spec specs) bool {
+	switch {
+	case spec.asSet:
+		return !isNestedListType(elemType)
+	case spec.asList || me.encodeSlicesAsLists:
+		return false
+	default:
+		return isSettableType(elemType) && !hasStdlibEncoding(elemType, spec)
+	}
+}
+
+func isNestedListType(typ reflect.Type) bool {
+	return (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) && !isBytesType(typ)
+}
+
+// marshalSet stores elements as SS, NS or BS depending on their representation, all the elements are expected
+// to have the same one; duplicates are dropped keeping the first occurrence, and empty set is not stored
+// as DynamoDB rejects both
+func (me *DdbMarshaller) marshalSet(value reflect.Value, spec specs) (*dynamodb.AttributeValue, error) {
+	if value.Len() == 0 {
+		return nil, nil
+	}
+	result := &dynamodb.AttributeValue{}
+	seen := make(map[string]bool, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		attr, err := me.ddbBasicMarshal(value.Index(i), spec)
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := setMemberKey(attr); ok {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		switch {
+		case attr != nil && attr.S != nil && result.NS == nil && result.BS == nil:
+			result.SS = append(result.SS, attr.S)
+		case attr != nil && attr.N != nil && result.SS == nil && result.BS == nil:
+			result.NS = append(result.NS, attr.N)
+		case attr != nil && attr.B != nil && result.SS == nil && result.NS == nil:
+			result.BS = append(result.BS, attr.B)
+		default:
+			return nil, errors.New(fmt.Sprintf("can't store %v as a set, elements should be all strings, numbers or binaries", value.Type()))
+		}
+	}
+	return result, nil
+}
+
+// setMemberKey returns the text of S, N or B attribute identifying the set member
+func setMemberKey(attr *dynamodb.AttributeValue) (string, bool) {
+	switch {
+	case attr == nil:
+		return "", false
+	case attr.S != nil:
+		return *attr.S, true
+	case attr.N != nil:
+		return *attr.N, true
+	case attr.B != nil:
+		return string(attr.B), true
+	}
+	return "", false
+}
+
+// unmarshalSet reads SS, NS or BS into the slice, elements are decoded one by one as S, N or B attributes
+func (me *DdbMarshaller) unmarshalSet(value reflect.Value, attr *dynamodb.AttributeValue, spec specs) error {
+	var elems []*dynamodb.AttributeValue
+	switch {
+	case attr.SS != nil:
+		for _, str := range attr.SS {
+			elems = append(elems, &dynamodb.AttributeValue{S: str})
+		}
+	case attr.NS != nil:
+		for _, str := range attr.NS {
+			elems = append(elems, &dynamodb.AttributeValue{N: str})
+		}
+	case attr.BS != nil:
+		for _, bin := range attr.BS {
+			elems = append(elems, &dynamodb.AttributeValue{B: bin})
+		}
+	default:
+		return typeMismatch(value.Type(), attr, "SS, NS, BS or L")
+	}
+	result := reflect.MakeSlice(value.Type(), len(elems), len(elems))
+	for i, elem := range elems {
+		if err := me.unmarshalValue(result.Index(i), elem, spec); err != nil {
+			index := fmt.Sprintf("[%d]", i)
+			return withPath(err, index, index)
+		}
+	}
+	value.Set(result)
+	return nil
+}
+
+// isSetMapType reports map[T]struct{} types, including Set[T]
+func isSetMapType(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Map && typ.Elem().Kind() == reflect.Struct && typ.Elem().NumField() == 0
+}
+
+// marshalMapSet stores keys of map[T]struct{} sorted, as a set; empty set is not stored
+func (me *DdbMarshaller) marshalMapSet(value reflect.Value, spec specs) (*dynamodb.AttributeValue, error) {
+	keys := value.MapKeys()
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	sortValues(keys)
+	if spec.asBinary && value.Type().Key().Kind() == reflect.String {
+		result := &dynamodb.AttributeValue{}
+		for _, k := range keys {
+			result.BS = append(result.BS, []byte(k.String()))
+		}
+		return result, nil
+	}
+	members := reflect.MakeSlice(reflect.SliceOf(value.Type().Key()), len(keys), len(keys))
+	for i, k := range keys {
+		members.Index(i).Set(k)
+	}
+	return me.marshalSet(members, spec)
+}
+
+// unmarshalMapSet reads map[T]struct{} from a set or a list
+func (me *DdbMarshaller) unmarshalMapSet(value reflect.Value, attr *dynamodb.AttributeValue, spec specs) error {
+	keyType := value.Type().Key()
+	keys := reflect.New(reflect.SliceOf(keyType)).Elem()
+	switch {
+	case keyType.Kind() == reflect.String && attr.BS != nil:
+		for _, bin := range attr.BS {
+			keys = reflect.Append(keys, reflect.ValueOf(string(bin)).Convert(keyType))
+		}
+	case attr.L != nil:
+		if err := me.setValueWithParsedList(keys, attr.L, spec); err != nil {
+			return err
+		}
+	default:
+		if err := me.unmarshalSet(keys, attr, spec); err != nil {
+			return err
+		}
+	}
+	return setMapSetKeys(value, keys)
+}
+
+func setMapSetKeys(value reflect.Value, keys reflect.Value) error {
+	result := reflect.MakeMapWithSize(value.Type(), keys.Len())
+	member := reflect.Zero(value.Type().Elem())
+	for i := 0; i < keys.Len(); i++ {
+		result.SetMapIndex(keys.Index(i), member)
+	}
+	value.Set(result)
+	return nil
+}
+
+// sortValues orders values of the same string or numeric kind, to keep the stored sets stable
+func sortValues(values []reflect.Value) {
+	sort.Slice(values, func(i, j int) bool {
+		a, b := values[i], values[j]
+		switch a.Kind() {
+		case reflect.String:
+			return a.String() < b.String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return a.Int() < b.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return a.Uint() < b.Uint()
+		case reflect.Float32, reflect.Float64:
+			return a.Float() < b.Float()
+		}
+		return false
+	})
+}
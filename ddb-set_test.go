@@ -0,0 +1,195 @@
+package ddbmarshal
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+	"testing"
+)
+
+type testSetOrList struct {
+	Default []string    `ddb:"default"`
+	Set     []string    `ddb:"set,set"`
+	List    []int       `ddb:"list,list"`
+	Bins    [][]byte    `ddb:"bins,list"`
+	Tokens  []testToken `ddb:"tokens,set"`
+	Empty   []string    `ddb:"empty"`
+}
+
+func TestDdbMarshaller_SetOrList(t *testing.T) {
+	source := &testSetOrList{
+		Default: []string{"a", "b"},
+		Set:     []string{"c"},
+		List:    []int{1, 1, 2},
+		Bins:    [][]byte{[]byte("x")},
+		Tokens:  []testToken{{1}, {2}},
+		Empty:   []string{},
+	}
+	tests := []struct {
+		name       string
+		asLists    bool
+		wantResult map[string]*dynamodb.AttributeValue
+	}{
+		{
+			name:    "sets by default",
+			asLists: false,
+			wantResult: map[string]*dynamodb.AttributeValue{
+				"default": {SS: aws.StringSlice([]string{"a", "b"})},
+				"set":     {SS: aws.StringSlice([]string{"c"})},
+				"list":    {L: []*dynamodb.AttributeValue{{N: aws.String("1")}, {N: aws.String("1")}, {N: aws.String("2")}}},
+				"bins":    {L: []*dynamodb.AttributeValue{{B: []byte("x")}}},
+				"tokens":  {SS: aws.StringSlice([]string{"tok-1", "tok-2"})},
+			},
+		},
+		{
+			name:    "lists by default",
+			asLists: true,
+			wantResult: map[string]*dynamodb.AttributeValue{
+				"default": {L: []*dynamodb.AttributeValue{{S: aws.String("a")}, {S: aws.String("b")}}},
+				"set":     {SS: aws.StringSlice([]string{"c"})},
+				"list":    {L: []*dynamodb.AttributeValue{{N: aws.String("1")}, {N: aws.String("1")}, {N: aws.String("2")}}},
+				"bins":    {L: []*dynamodb.AttributeValue{{B: []byte("x")}}},
+				"tokens":  {SS: aws.StringSlice([]string{"tok-1", "tok-2"})},
+				"empty":   {L: []*dynamodb.AttributeValue{}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			me := NewMarshaller()
+			me.SetEncodeSlicesAsLists(tt.asLists)
+			gotResult, err := me.Marshal(source)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(gotResult, tt.wantResult) {
+				t.Errorf("Marshal() gotResult = %v, want %v", gotResult, tt.wantResult)
+			}
+			var gotData testSetOrList
+			if err := me.Unmarshal(&gotData, gotResult); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			wantData := *source
+			if !tt.asLists {
+				wantData.Empty = nil
+			}
+			if !reflect.DeepEqual(&gotData, &wantData) {
+				t.Errorf("Unmarshal() gotData = %v, want %v", gotData, wantData)
+			}
+		})
+	}
+}
+
+func TestDdbMarshaller_SetDuplicates(t *testing.T) {
+	source := &struct {
+		Names  []string `ddb:"names,set"`
+		Counts [3]int   `ddb:"counts"`
+		Blobs  [][]byte `ddb:"blobs"`
+	}{[]string{"b", "a", "b", "a"}, [3]int{1, 1, 2}, [][]byte{{1}, {1}}}
+	got, err := NewMarshaller().Marshal(source)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := map[string]*dynamodb.AttributeValue{
+		"names":  {SS: aws.StringSlice([]string{"b", "a"})},
+		"counts": {NS: aws.StringSlice([]string{"1", "2"})},
+		"blobs":  {BS: [][]byte{{1}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Marshal() got = %v, want %v", got, want)
+	}
+}
+
+func TestDdbMarshaller_SetOfUnsupportedElements(t *testing.T) {
+	source := &struct {
+		Items []testLineItem `ddb:"items,set"`
+	}{[]testLineItem{{Sku: "a"}}}
+	if _, err := NewMarshaller().Marshal(source); err == nil {
+		t.Errorf("Marshal() error expected")
+	}
+}
+
+func TestDdbMarshaller_UnmarshalSetFromList(t *testing.T) {
+	var got struct {
+		Names  []string `ddb:"names"`
+		Counts []int    `ddb:"counts,list"`
+	}
+	err := NewMarshaller().Unmarshal(&got, map[string]*dynamodb.AttributeValue{
+		"names":  {L: []*dynamodb.AttributeValue{{S: aws.String("a")}, {S: aws.String("a")}}},
+		"counts": {NS: aws.StringSlice([]string{"3"})},
+	})
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if want := []string{"a", "a"}; !reflect.DeepEqual(got.Names, want) {
+		t.Errorf("Unmarshal() got = %v, want %v", got.Names, want)
+	}
+	if want := []int{3}; !reflect.DeepEqual(got.Counts, want) {
+		t.Errorf("Unmarshal() got = %v, want %v", got.Counts, want)
+	}
+}
+
+type testSets struct {
+	Tags     Set[string]             `ddb:"tags"`
+	Ids      Set[int64]              `ddb:"ids"`
+	Blobs    Set[string]             `ddb:"blobs,binary"`
+	Statuses map[testStatus]struct{} `ddb:"statuses"`
+	Empty    Set[string]             `ddb:"empty"`
+	NoBlobs  Set[string]             `ddb:"noBlobs,binary"`
+	NilBlobs Set[string]             `ddb:"nilBlobs,binary"`
+	Nested   map[string]Set[float64] `ddb:"nested"`
+}
+
+func prepareSetsStruct() *testSets {
+	return &testSets{
+		Tags:     NewSet("b", "a", "c"),
+		Ids:      NewSet[int64](10, -2, 3),
+		Blobs:    NewSet("\x00\x01"),
+		Statuses: map[testStatus]struct{}{"active": {}},
+		Empty:    NewSet[string](),
+		NoBlobs:  NewSet[string](),
+		Nested:   map[string]Set[float64]{"x": NewSet(1.5)},
+	}
+}
+
+func prepareSetsDdb() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"tags":     {SS: aws.StringSlice([]string{"a", "b", "c"})},
+		"ids":      {NS: aws.StringSlice([]string{"-2", "3", "10"})},
+		"blobs":    {BS: [][]byte{{0, 1}}},
+		"statuses": {SS: aws.StringSlice([]string{"active"})},
+		"nested":   {M: map[string]*dynamodb.AttributeValue{"x": {NS: aws.StringSlice([]string{"1.5"})}}},
+	}
+}
+
+func TestDdbMarshaller_Sets(t *testing.T) {
+	me := NewMarshaller()
+	got, err := me.Marshal(prepareSetsStruct())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := prepareSetsDdb(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Marshal() got = %v, want %v", got, want)
+	}
+	var data testSets
+	if err := me.Unmarshal(&data, got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := prepareSetsStruct()
+	want.Empty, want.NoBlobs = nil, nil
+	if !reflect.DeepEqual(&data, want) {
+		t.Errorf("Unmarshal() got = %v, want %v", data, want)
+	}
+}
+
+func TestSet(t *testing.T) {
+	var s Set[string]
+	s.Add("a", "b")
+	s.Remove("a")
+	if s.Has("a") || !s.Has("b") || s.Len() != 1 {
+		t.Errorf("Set = %v, want [b]", s.Values())
+	}
+	if want := []string{"b"}; !reflect.DeepEqual(s.Values(), want) {
+		t.Errorf("Values() = %v, want %v", s.Values(), want)
+	}
+}
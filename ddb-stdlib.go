@@ -0,0 +1,127 @@
+package ddbmarshal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Curated standard library types with built-in representation:
+//   - time.Duration is N of nanoseconds, or S of go duration ("1h30m") with "text" tag option
+//   - url.URL, net.IP and *time.Location are S; time.Location by value is rejected, as copying it is not safe
+//     (i.e. time.Local is initialised lazily)
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	urlType             = reflect.TypeOf(url.URL{})
+	ipType              = reflect.TypeOf(net.IP{})
+	locationType        = reflect.TypeOf(time.Location{})
+	locationPointerType = reflect.TypeOf((*time.Location)(nil))
+)
+
+// hasStdlibEncoding reports the types which representation differs from the one of their kind
+func hasStdlibEncoding(typ reflect.Type, spec specs) bool {
+	switch typ {
+	case urlType, ipType, locationType, locationPointerType:
+		return true
+	case durationType:
+		return spec.asText
+	}
+	return false
+}
+
+// marshalStdlib reports false if the value is not of the curated standard library types
+func marshalStdlib(value reflect.Value, spec specs) (*dynamodb.AttributeValue, bool, error) {
+	switch value.Type() {
+	case durationType:
+		duration := time.Duration(value.Int())
+		if spec.asText {
+			return &dynamodb.AttributeValue{S: aws.String(duration.String())}, true, nil
+		}
+		return &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(int64(duration), 10))}, true, nil
+	case urlType:
+		theUrl := value.Interface().(url.URL)
+		return &dynamodb.AttributeValue{S: aws.String(theUrl.String())}, true, nil
+	case ipType:
+		if value.Len() == 0 {
+			return nil, true, nil
+		}
+		return &dynamodb.AttributeValue{S: aws.String(value.Interface().(net.IP).String())}, true, nil
+	case locationPointerType:
+		if value.IsNil() {
+			return nil, true, nil
+		}
+		return &dynamodb.AttributeValue{S: aws.String(value.Interface().(*time.Location).String())}, true, nil
+	case locationType:
+		return nil, true, locationByValue()
+	}
+	return nil, false, nil
+}
+
+// unmarshalStdlib reports false if the value is not of the curated standard library types
+func unmarshalStdlib(value reflect.Value, attr *dynamodb.AttributeValue) (bool, error) {
+	switch value.Type() {
+	case durationType:
+		switch {
+		case attr.N != nil:
+			return true, setValueWithParsedNumber(value, *attr.N)
+		case attr.S != nil:
+			if duration, err := time.ParseDuration(*attr.S); err != nil {
+				return true, invalidValue(err)
+			} else {
+				value.SetInt(int64(duration))
+				return true, nil
+			}
+		}
+		return true, typeMismatch(value.Type(), attr, "N or S")
+	case urlType:
+		if attr.S == nil {
+			return true, typeMismatch(value.Type(), attr, "S")
+		}
+		if theUrl, err := url.Parse(*attr.S); err != nil {
+			return true, invalidValue(err)
+		} else {
+			value.Set(reflect.ValueOf(*theUrl))
+			return true, nil
+		}
+	case ipType:
+		switch {
+		case attr.S != nil:
+			if ip := net.ParseIP(*attr.S); ip == nil {
+				return true, invalidValue(errors.New(fmt.Sprintf("invalid IP address %q", *attr.S)))
+			} else {
+				value.SetBytes(ip)
+				return true, nil
+			}
+		case attr.B != nil:
+			if len(attr.B) != net.IPv4len && len(attr.B) != net.IPv6len {
+				return true, invalidValue(errors.New(fmt.Sprintf("invalid IP address length %d", len(attr.B))))
+			}
+			value.SetBytes(append([]byte(nil), attr.B...))
+			return true, nil
+		}
+		return true, typeMismatch(value.Type(), attr, "S or B")
+	case locationPointerType:
+		if attr.S == nil {
+			return true, typeMismatch(value.Type(), attr, "S")
+		}
+		location, err := time.LoadLocation(*attr.S)
+		if err != nil {
+			return true, invalidValue(err)
+		}
+		value.Set(reflect.ValueOf(location))
+		return true, nil
+	case locationType:
+		return true, locationByValue()
+	}
+	return false, nil
+}
+
+func locationByValue() error {
+	return fmt.Errorf("%w %v, use *time.Location", ErrUnsupportedType, locationType)
+}
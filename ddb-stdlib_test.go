@@ -0,0 +1,130 @@
+package ddbmarshal
+
+import (
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"net"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testStdlib struct {
+	Timeout   time.Duration            `ddb:"timeout"`
+	Interval  time.Duration            `ddb:"interval,text"`
+	Retries   []time.Duration          `ddb:"retries"`
+	Backoff   []time.Duration          `ddb:"backoff,text"`
+	Limits    map[string]time.Duration `ddb:"limits,text"`
+	Endpoint  *url.URL                 `ddb:"endpoint"`
+	Addr      net.IP                   `ddb:"addr"`
+	Allowed   []net.IP                 `ddb:"allowed"`
+	Zone      *time.Location           `ddb:"zone"`
+	NoAddress net.IP                   `ddb:"noAddress"`
+}
+
+func prepareStdlibStruct() *testStdlib {
+	endpoint, _ := url.Parse("https://example.com/path?q=1")
+	zone, _ := time.LoadLocation("UTC")
+	return &testStdlib{
+		Timeout:  1500 * time.Millisecond,
+		Interval: 90 * time.Minute,
+		Retries:  []time.Duration{time.Second, 2 * time.Second},
+		Backoff:  []time.Duration{time.Second, time.Second},
+		Limits:   map[string]time.Duration{"read": time.Minute},
+		Endpoint: endpoint,
+		Addr:     net.ParseIP("192.168.0.1"),
+		Allowed:  []net.IP{net.ParseIP("::1"), net.ParseIP("10.0.0.1")},
+		Zone:     zone,
+	}
+}
+
+func prepareStdlibDdb() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"timeout":  {N: aws.String("1500000000")},
+		"interval": {S: aws.String("1h30m0s")},
+		"retries":  {NS: aws.StringSlice([]string{"1000000000", "2000000000"})},
+		"backoff":  {L: []*dynamodb.AttributeValue{{S: aws.String("1s")}, {S: aws.String("1s")}}},
+		"limits":   {M: map[string]*dynamodb.AttributeValue{"read": {S: aws.String("1m0s")}}},
+		"endpoint": {S: aws.String("https://example.com/path?q=1")},
+		"addr":     {S: aws.String("192.168.0.1")},
+		"allowed":  {L: []*dynamodb.AttributeValue{{S: aws.String("::1")}, {S: aws.String("10.0.0.1")}}},
+		"zone":     {S: aws.String("UTC")},
+	}
+}
+
+func TestDdbMarshaller_Stdlib(t *testing.T) {
+	me := NewMarshaller()
+	got, err := me.Marshal(prepareStdlibStruct())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := prepareStdlibDdb(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Marshal() got = %v, want %v", got, want)
+	}
+	var data testStdlib
+	if err := me.Unmarshal(&data, got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if want := prepareStdlibStruct(); !reflect.DeepEqual(&data, want) {
+		t.Errorf("Unmarshal() got = %v, want %v", data, want)
+	}
+}
+
+func TestDdbMarshaller_UnmarshalStdlib(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   map[string]*dynamodb.AttributeValue
+		wantErr  bool
+		wantData *testStdlib
+	}{
+		{
+			name:     "duration as string without text option",
+			source:   map[string]*dynamodb.AttributeValue{"timeout": {S: aws.String("2s")}},
+			wantErr:  false,
+			wantData: &testStdlib{Timeout: 2 * time.Second},
+		},
+		{
+			name:     "binary IP address",
+			source:   map[string]*dynamodb.AttributeValue{"addr": {B: []byte{127, 0, 0, 1}}},
+			wantErr:  false,
+			wantData: &testStdlib{Addr: net.IP{127, 0, 0, 1}},
+		},
+		{
+			name:    "invalid IP address",
+			source:  map[string]*dynamodb.AttributeValue{"addr": {S: aws.String("localhost")}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown location",
+			source:  map[string]*dynamodb.AttributeValue{"zone": {S: aws.String("Nowhere/Never")}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			me := NewMarshaller()
+			var got testStdlib
+			if err := me.Unmarshal(&got, tt.source); (err != nil) != tt.wantErr {
+				t.Errorf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			} else if !tt.wantErr && !reflect.DeepEqual(&got, tt.wantData) {
+				t.Errorf("Unmarshal() got = %v, want %v", got, tt.wantData)
+			}
+		})
+	}
+}
+
+func TestDdbMarshaller_LocationByValue(t *testing.T) {
+	type byValue struct {
+		Zone time.Location `ddb:"zone"`
+	}
+	me := NewMarshaller()
+	if _, err := me.Marshal(&byValue{}); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Marshal() error = %v, want %v", err, ErrUnsupportedType)
+	}
+	err := me.Unmarshal(&byValue{}, map[string]*dynamodb.AttributeValue{"zone": {S: aws.String("Local")}})
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Unmarshal() error = %v, want %v", err, ErrUnsupportedType)
+	}
+}
@@ -0,0 +1,48 @@
+package ddbmarshal
+
+import (
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"strings"
+)
+
+// SetStrictUnmarshal makes Unmarshal fail on attributes not mapped to any struct field, at any nesting level.
+// All the known fields are still populated, the error lists all the unknown attributes
+func (marshaller *DdbMarshaller) SetStrictUnmarshal(value bool) {
+	marshaller.strictUnmarshal = value
+}
+
+// UnknownAttributesError lists the paths of the attributes not mapped to any struct field
+// in the strict mode, it matches ErrUnknownAttribute
+type UnknownAttributesError struct {
+	Attributes []string // attribute paths, i.e. address.zipCode
+}
+
+func (e *UnknownAttributesError) Error() string {
+	return "unknown attributes: " + strings.Join(e.Attributes, ", ")
+}
+
+func (e *UnknownAttributesError) Is(target error) bool {
+	return target == ErrUnknownAttribute
+}
+
+// unknownAttributes reports the attributes of the item not matching the struct fields, matched is the number of the
+// attributes used by the fields, so in the common case all the attributes are known without looking them up
+func unknownAttributes(fields []structField, source map[string]*dynamodb.AttributeValue, matched int) []string {
+	if matched == len(source) {
+		return nil
+	}
+	var result []string
+	for name := range source {
+		known := false
+		for i := range fields {
+			if fields[i].spec.name == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			result = append(result, name)
+		}
+	}
+	return result
+}
@@ -0,0 +1,87 @@
+package ddbmarshal
+
+import (
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+	"testing"
+)
+
+type testStrictItem struct {
+	Id      string                     `ddb:"id"`
+	Address *testErrAddress            `ddb:"address"`
+	Items   []testLineItem             `ddb:"items"`
+	ByName  map[string]testQuotaConfig `ddb:"byName"`
+}
+
+func prepareStrictDdb() map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{
+		"id":      {S: aws.String("x")},
+		"address": {M: map[string]*dynamodb.AttributeValue{"lines": {L: []*dynamodb.AttributeValue{{N: aws.String("1")}}}}},
+		"items": {L: []*dynamodb.AttributeValue{{M: map[string]*dynamodb.AttributeValue{
+			"sku": {S: aws.String("s")},
+		}}}},
+		"byName": {M: map[string]*dynamodb.AttributeValue{"a": {M: map[string]*dynamodb.AttributeValue{
+			"limit": {N: aws.String("1")},
+		}}}},
+	}
+}
+
+func TestDdbMarshaller_SetStrictUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(item map[string]*dynamodb.AttributeValue)
+		unknown []string
+	}{
+		{
+			name:   "all known",
+			modify: func(map[string]*dynamodb.AttributeValue) {},
+		},
+		{
+			name: "unknown at every level",
+			modify: func(item map[string]*dynamodb.AttributeValue) {
+				item["extra"] = &dynamodb.AttributeValue{S: aws.String("e")}
+				item["address"].M["zipCode"] = &dynamodb.AttributeValue{N: aws.String("1")}
+				item["items"].L[0].M["price"] = &dynamodb.AttributeValue{N: aws.String("1")}
+				item["byName"].M["a"].M["burst"] = &dynamodb.AttributeValue{N: aws.String("1")}
+			},
+			unknown: []string{"address.zipCode", "byName.a.burst", "extra", "items[0].price"},
+		},
+		{
+			name: "known attribute with NULL",
+			modify: func(item map[string]*dynamodb.AttributeValue) {
+				item["address"] = &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := prepareStrictDdb()
+			tt.modify(item)
+			me := NewMarshaller()
+			if err := me.Unmarshal(&testStrictItem{}, item); err != nil {
+				t.Fatalf("Unmarshal() error = %v in non-strict mode", err)
+			}
+			me.SetStrictUnmarshal(true)
+			var data testStrictItem
+			err := me.Unmarshal(&data, item)
+			if tt.unknown == nil {
+				if err != nil {
+					t.Errorf("Unmarshal() error = %v", err)
+				}
+				return
+			}
+			var unknownErr *UnknownAttributesError
+			if !errors.Is(err, ErrUnknownAttribute) || !errors.As(err, &unknownErr) {
+				t.Fatalf("Unmarshal() error = %v, want %v", err, ErrUnknownAttribute)
+			}
+			if !reflect.DeepEqual(unknownErr.Attributes, tt.unknown) {
+				t.Errorf("Unmarshal() unknown = %v, want %v", unknownErr.Attributes, tt.unknown)
+			}
+			if data.Id != "x" || data.Items[0].Sku != "s" || data.ByName["a"].Limit != 1 {
+				t.Errorf("Unmarshal() known fields are not populated: %v", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package ddbmarshal
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"strconv"
+	"time"
+)
+
+// Time formats, usable as tag options (i.e. `ddb:"created,rfc3339"`) and with SetTimeFormat;
+// any other time layout may be given with "layout=" tag option or to SetTimeFormat
+const (
+	TimeFormatUnix        = "unix"
+	TimeFormatUnixMilli   = "unixms"
+	TimeFormatUnixNano    = "unixnano"
+	TimeFormatRFC3339     = "rfc3339"
+	TimeFormatRFC3339Nano = "rfc3339nano"
+)
+
+func isTimeFormat(item string) bool {
+	switch item {
+	case TimeFormatUnix, TimeFormatUnixMilli, TimeFormatUnixNano, TimeFormatRFC3339, TimeFormatRFC3339Nano:
+		return true
+	}
+	return false
+}
+
+// SetTimeFormat sets default time.Time encoding for the fields without time format tag option,
+// the format is one of TimeFormat* constants or a time layout; "ttl-ts" fields stay unix seconds
+func (marshaller *DdbMarshaller) SetTimeFormat(format string) {
+	marshaller.timeFormat = format
+}
+
+func (me *DdbMarshaller) timeFormatOf(spec specs) string {
+	switch {
+	case spec.timeFormat != "":
+		return spec.timeFormat
+	case spec.isTtlField || me.timeFormat == "":
+		return TimeFormatUnix
+	default:
+		return me.timeFormat
+	}
+}
+
+func isNumericTimeFormat(format string) bool {
+	return format == TimeFormatUnix || format == TimeFormatUnixMilli || format == TimeFormatUnixNano
+}
+
+func timeLayout(format string) string {
+	switch format {
+	case TimeFormatRFC3339:
+		return time.RFC3339
+	case TimeFormatRFC3339Nano, TimeFormatUnix, TimeFormatUnixMilli, TimeFormatUnixNano:
+		return time.RFC3339Nano
+	default:
+		return format
+	}
+}
+
+func formatTime(value time.Time, format string) string {
+	switch format {
+	case TimeFormatUnix:
+		return strconv.FormatInt(value.Unix(), 10)
+	case TimeFormatUnixMilli:
+		return strconv.FormatInt(value.UnixMilli(), 10)
+	case TimeFormatUnixNano:
+		return strconv.FormatInt(value.UnixNano(), 10)
+	default:
+		return value.Format(timeLayout(format))
+	}
+}
+
+func (me *DdbMarshaller) marshalTime(value time.Time, spec specs) *dynamodb.AttributeValue {
+	format := me.timeFormatOf(spec)
+	if isNumericTimeFormat(format) {
+		return &dynamodb.AttributeValue{N: aws.String(formatTime(value, format))}
+	}
+	return &dynamodb.AttributeValue{S: aws.String(formatTime(value, format))}
+}
+
+// parseNumericTime reads epoch time in the units of the format, seconds for the string formats
+func parseNumericTime(str string, format string) (time.Time, error) {
+	val, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch format {
+	case TimeFormatUnixMilli:
+		return time.UnixMilli(val).UTC(), nil
+	case TimeFormatUnixNano:
+		return time.Unix(0, val).UTC(), nil
+	default:
+		return time.Unix(val, 0).UTC(), nil
+	}
+}
+
+// unmarshalTime accepts both N and S attributes regardless of the format, to allow migration between the formats
+func (me *DdbMarshaller) unmarshalTime(attr *dynamodb.AttributeValue, spec specs) (time.Time, error) {
+	format := me.timeFormatOf(spec)
+	switch {
+	case attr.N != nil:
+		val, err := parseNumericTime(*attr.N, format)
+		return val, invalidValue(err)
+	case attr.S != nil:
+		val, err := time.Parse(timeLayout(format), *attr.S)
+		return val, invalidValue(err)
+	default:
+		return time.Time{}, typeMismatch(timeType, attr, "N or S")
+	}
+}
+
+func validateTimeLayout(layout string) error {
+	if layout == "" {
+		return errors.New(fmt.Sprintf("empty time layout in %q option", TagItemLayout))
+	}
+	return nil
+}
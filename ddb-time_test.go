@@ -0,0 +1,123 @@
+package ddbmarshal
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testTimes struct {
+	Unix    time.Time   `ddb:"unix"`
+	Milli   time.Time   `ddb:"milli,unixms"`
+	Nano    time.Time   `ddb:"nano,unixnano"`
+	Seconds time.Time   `ddb:"seconds,rfc3339"`
+	Precise time.Time   `ddb:"precise,rfc3339nano"`
+	Day     time.Time   `ddb:"day,layout=2006-01-02"`
+	Expire  time.Time   `ddb:"expire,ttl-ts"`
+	Dates   []time.Time `ddb:"dates,layout=2006-01-02"`
+	Stamp   time.Time   `ddb:"stamp,layout='Mon, 02 Jan 2006 15:04:05 MST'"`
+}
+
+var testInstant = time.Date(2022, 2, 2, 22, 2, 20, 123456789, time.UTC)
+
+func TestDdbMarshaller_TimeFormats(t *testing.T) {
+	tests := []struct {
+		name       string
+		format     string
+		wantResult map[string]*dynamodb.AttributeValue
+		wantData   *testTimes
+	}{
+		{
+			name:   "default format",
+			format: "",
+			wantResult: map[string]*dynamodb.AttributeValue{
+				"unix":    {N: aws.String("1643839340")},
+				"milli":   {N: aws.String("1643839340123")},
+				"nano":    {N: aws.String("1643839340123456789")},
+				"seconds": {S: aws.String("2022-02-02T22:02:20Z")},
+				"precise": {S: aws.String("2022-02-02T22:02:20.123456789Z")},
+				"day":     {S: aws.String("2022-02-02")},
+				"expire":  {N: aws.String("1643839340")},
+				"dates":   {SS: aws.StringSlice([]string{"2022-02-02"})},
+				"stamp":   {S: aws.String("Wed, 02 Feb 2022 22:02:20 UTC")},
+			},
+			wantData: &testTimes{
+				Unix:    testInstant.Truncate(time.Second),
+				Milli:   testInstant.Truncate(time.Millisecond),
+				Nano:    testInstant,
+				Seconds: testInstant.Truncate(time.Second),
+				Precise: testInstant,
+				Day:     testInstant.Truncate(24 * time.Hour),
+				Expire:  testInstant.Truncate(time.Second),
+				Dates:   []time.Time{testInstant.Truncate(24 * time.Hour)},
+				Stamp:   testInstant.Truncate(time.Second),
+			},
+		},
+		{
+			name:   "marshaller-wide format",
+			format: TimeFormatRFC3339Nano,
+			wantResult: map[string]*dynamodb.AttributeValue{
+				"unix":    {S: aws.String("2022-02-02T22:02:20.123456789Z")},
+				"milli":   {N: aws.String("1643839340123")},
+				"nano":    {N: aws.String("1643839340123456789")},
+				"seconds": {S: aws.String("2022-02-02T22:02:20Z")},
+				"precise": {S: aws.String("2022-02-02T22:02:20.123456789Z")},
+				"day":     {S: aws.String("2022-02-02")},
+				"expire":  {N: aws.String("1643839340")},
+				"dates":   {SS: aws.StringSlice([]string{"2022-02-02"})},
+				"stamp":   {S: aws.String("Wed, 02 Feb 2022 22:02:20 UTC")},
+			},
+			wantData: &testTimes{
+				Unix:    testInstant,
+				Milli:   testInstant.Truncate(time.Millisecond),
+				Nano:    testInstant,
+				Seconds: testInstant.Truncate(time.Second),
+				Precise: testInstant,
+				Day:     testInstant.Truncate(24 * time.Hour),
+				Expire:  testInstant.Truncate(time.Second),
+				Dates:   []time.Time{testInstant.Truncate(24 * time.Hour)},
+				Stamp:   testInstant.Truncate(time.Second),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			me := NewMarshaller()
+			me.SetTimeFormat(tt.format)
+			source := &testTimes{testInstant, testInstant, testInstant, testInstant, testInstant, testInstant, testInstant,
+				[]time.Time{testInstant}, testInstant}
+			gotResult, err := me.Marshal(source)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(gotResult, tt.wantResult) {
+				t.Errorf("Marshal() gotResult = %v, want %v", gotResult, tt.wantResult)
+			}
+			var gotData testTimes
+			if err := me.Unmarshal(&gotData, gotResult); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(&gotData, tt.wantData) {
+				t.Errorf("Unmarshal() gotData = %v, want %v", gotData, tt.wantData)
+			}
+		})
+	}
+}
+
+func TestDdbMarshaller_UnmarshalTimeMigration(t *testing.T) {
+	me := NewMarshaller()
+	var got testTimes
+	err := me.Unmarshal(&got, map[string]*dynamodb.AttributeValue{
+		"unix":    {S: aws.String("2022-02-02T22:02:20.123456789Z")},
+		"seconds": {N: aws.String("1643839340")},
+	})
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	want := &testTimes{Unix: testInstant, Seconds: testInstant.Truncate(time.Second)}
+	if !reflect.DeepEqual(&got, want) {
+		t.Errorf("Unmarshal() got = %v, want %v", got, want)
+	}
+}
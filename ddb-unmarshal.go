@@ -1,13 +1,12 @@
 package ddbmarshal
 
 import (
-	"errors"
+	"encoding"
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"reflect"
 	"strconv"
-	"time"
 )
 
 func (me *DdbMarshaller) Unmarshal(target interface{}, source map[string]*dynamodb.AttributeValue) error {
@@ -15,382 +14,294 @@ func (me *DdbMarshaller) Unmarshal(target interface{}, source map[string]*dynamo
 	if err != nil {
 		return err
 	}
-	for i, I := 0, targetValue.NumField(); i < I; i++ {
-		fieldType := targetValue.Type().Field(i)
-		if ddbSpec, ok := fieldType.Tag.Lookup(TagDdb); ok {
-			if !fieldType.IsExported() {
-				return errors.New("can't use ddb field for unexported fieldType " + fieldType.Name)
+	return me.unmarshalStruct(targetValue, me.addPrefixToTheFieldNames, source)
+}
+
+// unmarshalStruct populates fields of the struct value from the attributes,
+// it is used for the top level item as well as for the nested structs, the names are resolved same way as on marshal;
+// the attributes not mapped to the fields are stored to the "remain" field if there is one.
+// Missing required attributes are all reported before populating any field, so the struct is not changed then;
+// the validation rules are checked for the populated fields, all the violations are reported as ValidationErrors
+func (me *DdbMarshaller) unmarshalStruct(targetValue reflect.Value, prefix string, source map[string]*dynamodb.AttributeValue) error {
+	fields, err := me.structFields(targetValue.Type(), prefix)
+	if err != nil {
+		return err
+	}
+	var deferred deferredErrors
+	for _, field := range fields {
+		if field.spec.required && field.defaultAttr == nil && source[field.spec.name] == nil {
+			deferred.violations = append(deferred.violations, &UnmarshalError{Field: field.goName, Attribute: field.spec.name, Err: ErrMissingRequired})
+		}
+	}
+	if len(deferred.violations) > 0 {
+		return deferred.err()
+	}
+	var remain *structField
+	matched := 0
+	for i, field := range fields {
+		if field.spec.remain {
+			remain = &fields[i]
+			continue
+		}
+		present := false
+		if attrVal, ok := source[field.spec.name]; !ok || attrVal == nil {
+			if ok {
+				matched++
 			}
-			if specs, err := ParseDdbTag(ddbSpec); err != nil {
-				return err
-			} else {
-				if attrVal := source[specs.name]; attrVal == nil {
-					if specs.required {
-						return errors.New(fmt.Sprintf("missing required field (gp: %s ddb: %s)", fieldType.Name, specs.name))
-					}
-				} else {
-					fieldValue := targetValue.Field(i)
-					switch fieldValue.Interface().(type) {
-					case bool:
-						fieldValue.Set(reflect.ValueOf(*attrVal.BOOL))
-					case string:
-						fieldValue.Set(reflect.ValueOf(*attrVal.S))
-					case []string:
-						fieldValue.Set(reflect.ValueOf(aws.StringValueSlice(attrVal.SS)))
-					case int, uint, int64, uint64, float32, float64, time.Time:
-						if err := setValueWithParsedNumber(fieldValue, *attrVal.N); err != nil {
-							return err
-						}
-					case []int, []uint, []int64, []uint64, []float32, []float64, []time.Time:
-						if err := setValueWithParsedNumbers(fieldValue, attrVal.NS); err != nil {
-							return err
-						}
-					case []byte:
-						fieldValue.Set(reflect.ValueOf(attrVal.B))
-					case [][]byte:
-						fieldValue.Set(reflect.ValueOf(attrVal.BS))
-					case
-						map[string]string,
-						map[string]int,
-						map[string]uint,
-						map[string]int64,
-						map[string]uint64,
-						map[string]float32,
-						map[string]float64,
-						map[string]time.Time:
-						if err := setValueWithParsedMap(fieldValue, attrVal.M); err != nil {
-							return err
-						}
-					default:
-						return errors.New(fmt.Sprintf("Unsupported field type %v", fieldValue.Interface()))
-					}
+			if field.defaultAttr != nil {
+				if err := me.unmarshalValue(fieldForWrite(targetValue, field.index), field.defaultAttr, field.spec); err != nil {
+					return withPath(err, field.goName, field.spec.name)
+				}
+				present = true
+			}
+		} else {
+			matched++
+			if err := me.unmarshalValue(fieldForWrite(targetValue, field.index), attrVal, field.spec); err != nil {
+				if err := deferred.collect(err, field.goName, field.spec.name); err != nil {
+					return err
 				}
 			}
+			present = !aws.BoolValue(attrVal.NULL)
+		}
+		if field.spec.rules != nil {
+			deferred.checkRules(targetValue, field, present)
 		}
 	}
-	return nil
-}
-
-func setValueWithParsedMap(value reflect.Value, attrs map[string]*dynamodb.AttributeValue) error {
-	switch value.Interface().(type) {
-	case map[string]string:
-		return setMapOfStrings(value, attrs)
-	case map[string]int:
-		return setMapOfInts(value, attrs)
-	case map[string]uint:
-		return setMapOfUints(value, attrs)
-	case map[string]int64:
-		return setMapOfInt64(value, attrs)
-	case map[string]uint64:
-		return setMapOfUint64(value, attrs)
-	case map[string]float32:
-		return setMapOfFloat32(value, attrs)
-	case map[string]float64:
-		return setMapOfFloat64(value, attrs)
-	case map[string]time.Time:
-		return setMapOfTime(value, attrs)
-	default:
-		return errors.New(fmt.Sprintf("Unsupported map value type %v", value.Interface()))
+	if remain != nil {
+		var rest map[string]*dynamodb.AttributeValue
+		for _, name := range unknownAttributes(fields, source, matched) {
+			if rest == nil {
+				rest = make(map[string]*dynamodb.AttributeValue)
+			}
+			rest[name] = source[name]
+		}
+		fieldForWrite(targetValue, remain.index).Set(reflect.ValueOf(rest))
+	} else if me.strictUnmarshal {
+		deferred.unknown = append(deferred.unknown, unknownAttributes(fields, source, matched)...)
 	}
-
+	return deferred.err()
 }
 
-func setMapOfStrings(value reflect.Value, attrs map[string]*dynamodb.AttributeValue) error {
-	result := make(map[string]string)
-	for k, v := range attrs {
-		// TODO: add nil protection
-		result[k] = *v.S
+// unmarshalValue gives precedence to registered converters and DdbAttributeUnmarshaler implementations, otherwise dispatches on the kind
+// of the target, so named types are handled the same way as their underlying types; encoding.TextUnmarshaler
+// and encoding.BinaryUnmarshaler are used for S and B attributes same way as on marshal.
+// Attribute of the type not matching the go type is reported as UnmarshalTypeError, nil attribute is read as NULL
+func (me *DdbMarshaller) unmarshalValue(fieldValue reflect.Value, attrVal *dynamodb.AttributeValue, spec specs) error {
+	if attrVal == nil {
+		attrVal = &dynamodb.AttributeValue{NULL: aws.Bool(true)}
 	}
-	value.Set(reflect.ValueOf(result))
-	return nil
-}
-
-func setMapOfInts(value reflect.Value, attrs map[string]*dynamodb.AttributeValue) error {
-	result := make(map[string]int)
-	for k, v := range attrs {
-		// TODO: add nil protection
-		if val, err := strconv.ParseInt(*v.N, 0, 32); err != nil {
+	hooks := hooksOf(fieldValue.Type())
+	if hooks.has(hookOptional) {
+		if ok, err := me.unmarshalOptional(fieldValue, attrVal, spec); ok {
 			return err
-		} else {
-			result[k] = int(val)
 		}
 	}
-	value.Set(reflect.ValueOf(result))
-	return nil
-}
-
-func setMapOfUints(value reflect.Value, attrs map[string]*dynamodb.AttributeValue) error {
-	result := make(map[string]uint)
-	for k, v := range attrs {
-		// TODO: add nil protection
-		if val, err := strconv.ParseUint(*v.N, 0, 32); err != nil {
+	if aws.BoolValue(attrVal.NULL) {
+		fieldValue.Set(reflect.Zero(fieldValue.Type()))
+		return nil
+	}
+	if ok, err := me.unmarshalConverted(fieldValue, attrVal); ok {
+		return err
+	}
+	if hooks.has(hookUnmarshaler) && fieldValue.Kind() != reflect.Ptr && fieldValue.CanAddr() {
+		if ok, err := unmarshalCustom(fieldValue.Addr(), attrVal); ok {
 			return err
-		} else {
-			result[k] = uint(val)
 		}
 	}
-	value.Set(reflect.ValueOf(result))
-	return nil
-}
-
-func setMapOfInt64(value reflect.Value, attrs map[string]*dynamodb.AttributeValue) error {
-	result := make(map[string]int64)
-	for k, v := range attrs {
-		// TODO: add nil protection
-		if val, err := strconv.ParseInt(*v.N, 0, 64); err != nil {
+	if hooks.has(hookStdlib) {
+		if ok, err := unmarshalStdlib(fieldValue, attrVal); ok {
 			return err
-		} else {
-			result[k] = val
 		}
 	}
-	value.Set(reflect.ValueOf(result))
-	return nil
-}
-
-func setMapOfUint64(value reflect.Value, attrs map[string]*dynamodb.AttributeValue) error {
-	result := make(map[string]uint64)
-	for k, v := range attrs {
-		// TODO: add nil protection
-		if val, err := strconv.ParseUint(*v.N, 0, 64); err != nil {
+	if hooks.has(hookBigNumber) {
+		if ok, err := unmarshalBigNumber(fieldValue, attrVal); ok {
 			return err
-		} else {
-			result[k] = val
 		}
 	}
-	value.Set(reflect.ValueOf(result))
-	return nil
-}
-
-func setMapOfFloat32(value reflect.Value, attrs map[string]*dynamodb.AttributeValue) error {
-	result := make(map[string]float32)
-	for k, v := range attrs {
-		// TODO: add nil protection
-		if val, err := strconv.ParseFloat(*v.N, 32); err != nil {
+	if hooks.has(hookTextUnmarshaler) && (spec.asText || spec.asBinary || !isScalarType(fieldValue.Type())) {
+		if ok, err := unmarshalTextOrBinary(fieldValue, attrVal); ok {
 			return err
-		} else {
-			result[k] = float32(val)
 		}
 	}
-	value.Set(reflect.ValueOf(result))
-	return nil
-}
-
-func setMapOfFloat64(value reflect.Value, attrs map[string]*dynamodb.AttributeValue) error {
-	result := make(map[string]float64)
-	for k, v := range attrs {
-		// TODO: add nil protection
-		if val, err := strconv.ParseFloat(*v.N, 64); err != nil {
-			return err
-		} else {
-			result[k] = val
+	switch fieldValue.Kind() {
+	case reflect.Ptr:
+		if fieldValue.IsNil() {
+			fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+		}
+		return me.unmarshalValue(fieldValue.Elem(), attrVal, spec)
+	case reflect.Interface:
+		if fieldValue.NumMethod() == 0 {
+			if val, err := me.attributeToInterface(attrVal); err != nil {
+				return err
+			} else if val != nil {
+				fieldValue.Set(reflect.ValueOf(val))
+			} else {
+				fieldValue.Set(reflect.Zero(fieldValue.Type()))
+			}
+			return nil
 		}
 	}
-	value.Set(reflect.ValueOf(result))
-	return nil
-}
-
-func setMapOfTime(value reflect.Value, attrs map[string]*dynamodb.AttributeValue) error {
-	result := make(map[string]time.Time)
-	for k, v := range attrs {
-		// TODO: add nil protection
-		if val, err := strconv.ParseInt(*v.N, 0, 64); err != nil {
+	if fieldValue.Type() == timeType {
+		if val, err := me.unmarshalTime(attrVal, spec); err != nil {
 			return err
 		} else {
-			result[k] = time.Unix(val, 0)
+			fieldValue.Set(reflect.ValueOf(val))
+			return nil
 		}
 	}
-	value.Set(reflect.ValueOf(result))
-	return nil
-}
-
-func setValueWithParsedNumbers(value reflect.Value, strings []*string) error {
-	switch value.Interface().(type) {
-	case []int:
-		if result, err := parseIntegers(strings); err != nil {
-			return err
-		} else {
-			value.Set(reflect.ValueOf(result))
+	if isNumberType(fieldValue.Type()) {
+		if attrVal.N == nil {
+			return typeMismatch(fieldValue.Type(), attrVal, "N")
 		}
-	case []uint:
-		if result, err := parseUnsignedIntegers(strings); err != nil {
-			return err
-		} else {
-			value.Set(reflect.ValueOf(result))
+		return setValueWithParsedNumber(fieldValue, *attrVal.N)
+	}
+	switch fieldValue.Kind() {
+	case reflect.Bool:
+		if attrVal.BOOL == nil {
+			return typeMismatch(fieldValue.Type(), attrVal, "BOOL")
 		}
-	case []int64:
-		if result, err := parseInt64Slice(strings); err != nil {
-			return err
-		} else {
-			value.Set(reflect.ValueOf(result))
+		fieldValue.SetBool(*attrVal.BOOL)
+		return nil
+	case reflect.String:
+		if attrVal.S == nil {
+			return typeMismatch(fieldValue.Type(), attrVal, "S")
 		}
-	case []uint64:
-		if result, err := parseUint64Slice(strings); err != nil {
-			return err
-		} else {
-			value.Set(reflect.ValueOf(result))
+		fieldValue.SetString(*attrVal.S)
+		return nil
+	case reflect.Slice:
+		if fieldValue.Type().Elem().Kind() == reflect.Uint8 {
+			return unmarshalBytes(fieldValue, attrVal)
 		}
-	case []float32:
-		if result, err := parseFloat32Slice(strings); err != nil {
-			return err
-		} else {
-			value.Set(reflect.ValueOf(result))
+		if attrVal.L != nil {
+			return me.setValueWithParsedList(fieldValue, attrVal.L, spec)
 		}
-	case []float64:
-		if result, err := parseFloat64Slice(strings); err != nil {
-			return err
-		} else {
-			value.Set(reflect.ValueOf(result))
+		return me.unmarshalSet(fieldValue, attrVal, spec)
+	case reflect.Array:
+		if fieldValue.Type().Elem().Kind() == reflect.Uint8 {
+			return unmarshalBytes(fieldValue, attrVal)
 		}
-	case []time.Time:
-		if result, err := parseTimeSlice(strings); err != nil {
+		elems := reflect.New(reflect.SliceOf(fieldValue.Type().Elem())).Elem()
+		if attrVal.L != nil {
+			if err := me.setValueWithParsedList(elems, attrVal.L, spec); err != nil {
+				return err
+			}
+		} else if err := me.unmarshalSet(elems, attrVal, spec); err != nil {
 			return err
-		} else {
-			value.Set(reflect.ValueOf(result))
 		}
-	default:
-		return errors.New(fmt.Sprintf("Unsupported numeric type %v", value.Interface()))
-	}
-	return nil
-}
-
-func parseIntegers(strings []*string) ([]int, error) {
-	result := make([]int, 0)
-	for _, v := range strings {
-		if val, err := strconv.ParseInt(*v, 0, 32); err != nil {
-			return nil, err
-		} else {
-			result = append(result, int(val))
+		return setArrayFromSlice(fieldValue, elems)
+	case reflect.Map:
+		if isSetMapType(fieldValue.Type()) {
+			return me.unmarshalMapSet(fieldValue, attrVal, spec)
 		}
-	}
-	return result, nil
-}
-
-func parseUnsignedIntegers(strings []*string) ([]uint, error) {
-	result := make([]uint, 0)
-	for _, v := range strings {
-		if val, err := strconv.ParseUint(*v, 0, 32); err != nil {
-			return nil, err
-		} else {
-			result = append(result, uint(val))
+		if attrVal.M == nil {
+			return typeMismatch(fieldValue.Type(), attrVal, "M")
 		}
-	}
-	return result, nil
-}
-
-func parseInt64Slice(strings []*string) ([]int64, error) {
-	result := make([]int64, 0)
-	for _, v := range strings {
-		if val, err := strconv.ParseInt(*v, 0, 64); err != nil {
-			return nil, err
-		} else {
-			result = append(result, val)
+		return me.setValueWithParsedMap(fieldValue, attrVal.M, spec)
+	case reflect.Struct:
+		if attrVal.M == nil {
+			return typeMismatch(fieldValue.Type(), attrVal, "M")
 		}
+		return me.unmarshalStruct(fieldValue, "", attrVal.M)
 	}
-	return result, nil
+	return unsupportedType(fieldValue.Type())
 }
 
-func parseUint64Slice(strings []*string) ([]uint64, error) {
-	result := make([]uint64, 0)
-	for _, v := range strings {
-		if val, err := strconv.ParseUint(*v, 0, 64); err != nil {
-			return nil, err
-		} else {
-			result = append(result, val)
+func (me *DdbMarshaller) setValueWithParsedList(value reflect.Value, attrs []*dynamodb.AttributeValue, spec specs) error {
+	var deferred deferredErrors
+	result := reflect.MakeSlice(value.Type(), len(attrs), len(attrs))
+	for i, attr := range attrs {
+		if err := me.unmarshalValue(result.Index(i), attr, spec); err != nil {
+			index := fmt.Sprintf("[%d]", i)
+			if err := deferred.collect(err, index, index); err != nil {
+				return err
+			}
 		}
 	}
-	return result, nil
+	value.Set(result)
+	return deferred.err()
 }
 
-func parseFloat32Slice(strings []*string) ([]float32, error) {
-	result := make([]float32, 0)
-	for _, v := range strings {
-		if val, err := strconv.ParseFloat(*v, 32); err != nil {
-			return nil, err
-		} else {
-			result = append(result, float32(val))
+func (me *DdbMarshaller) setValueWithParsedMap(value reflect.Value, attrs map[string]*dynamodb.AttributeValue, spec specs) error {
+	mapType := value.Type()
+	if !isMapKeyType(mapType.Key()) {
+		return unsupportedType(mapType)
+	}
+	var deferred deferredErrors
+	result := reflect.MakeMapWithSize(mapType, len(attrs))
+	for k, v := range attrs {
+		key, err := parseMapKey(mapType.Key(), k)
+		if err != nil {
+			return withPath(err, "["+k+"]", k)
+		}
+		elem := reflect.New(mapType.Elem()).Elem()
+		if err := me.unmarshalValue(elem, v, spec); err != nil {
+			if err := deferred.collect(err, "["+k+"]", k); err != nil {
+				return err
+			}
 		}
+		result.SetMapIndex(key, elem)
 	}
-	return result, nil
+	value.Set(result)
+	return deferred.err()
 }
 
-func parseFloat64Slice(strings []*string) ([]float64, error) {
-	result := make([]float64, 0)
-	for _, v := range strings {
-		if val, err := strconv.ParseFloat(*v, 64); err != nil {
-			return nil, err
-		} else {
-			result = append(result, val)
-		}
+// parseMapKey checks string kind first, then encoding.TextUnmarshaler, then numbers, same as formatMapKey
+func parseMapKey(keyType reflect.Type, str string) (reflect.Value, error) {
+	key := reflect.New(keyType).Elem()
+	if keyType.Kind() == reflect.String {
+		key.SetString(str)
+		return key, nil
 	}
-	return result, nil
+	if unmarshaler, ok := asInterface(key.Addr(), textUnmarshalerType).(encoding.TextUnmarshaler); ok {
+		return key, invalidValue(unmarshaler.UnmarshalText([]byte(str)))
+	}
+	return parseStringToNumber(keyType, str)
 }
 
-func parseTimeSlice(strings []*string) ([]time.Time, error) {
-	result := make([]time.Time, 0)
-	for _, v := range strings {
-		if val, err := strconv.ParseInt(*v, 0, 64); err != nil {
-			return nil, err
-		} else {
-			result = append(result, time.Unix(val, 0))
+func setValueWithParsedNumbers(value reflect.Value, strings []*string) error {
+	result := reflect.MakeSlice(value.Type(), len(strings), len(strings))
+	for i, str := range strings {
+		if err := setValueWithParsedNumber(result.Index(i), aws.StringValue(str)); err != nil {
+			return err
 		}
 	}
-	return result, nil
+	value.Set(result)
+	return nil
 }
 
 func setValueWithParsedNumber(value reflect.Value, str string) error {
-	if val, err := parseStringToNumber(value.Interface(), str); err != nil {
+	if val, err := parseStringToNumber(value.Type(), str); err != nil {
 		return err
 	} else {
-		value.Set(reflect.ValueOf(val))
+		value.Set(val)
 		return nil
 	}
 }
 
-func parseStringToNumber(typ interface{}, str string) (interface{}, error) {
-	switch typ.(type) {
-	case int:
-		if val, err := strconv.ParseInt(str, 0, 32); err != nil {
-			return 0, err
-		} else {
-			return int(val), nil
-		}
-	case int64:
-		if val, err := strconv.ParseInt(str, 0, 64); err != nil {
-			return 0, err
-		} else {
-			return val, nil
-		}
-	case uint:
-		if val, err := strconv.ParseUint(str, 0, 32); err != nil {
-			return 0, err
-		} else {
-			return uint(val), nil
-		}
-	case uint64:
-		if val, err := strconv.ParseUint(str, 0, 64); err != nil {
-			return 0, err
-		} else {
-			return val, nil
-		}
-	case float32:
-		if val, err := strconv.ParseFloat(str, 32); err != nil {
-			return 0, err
+// parseStringToNumber parses the number into the value of the given numeric kind, values not fitting the type are rejected
+func parseStringToNumber(typ reflect.Type, str string) (reflect.Value, error) {
+	result := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if val, err := strconv.ParseInt(str, 10, typ.Bits()); err != nil {
+			return result, invalidValue(err)
 		} else {
-			return float64(val), nil
+			result.SetInt(val)
 		}
-	case float64:
-		if val, err := strconv.ParseFloat(str, 64); err != nil {
-			return 0, err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if val, err := strconv.ParseUint(str, 10, typ.Bits()); err != nil {
+			return result, invalidValue(err)
 		} else {
-			return val, nil
+			result.SetUint(val)
 		}
-	case time.Time:
-		if val, err := strconv.ParseInt(str, 0, 64); err != nil {
-			return 0, err
+	case reflect.Float32, reflect.Float64:
+		if val, err := strconv.ParseFloat(str, typ.Bits()); err != nil {
+			return result, invalidValue(err)
 		} else {
-			return time.Unix(val, 0).UTC(), nil
+			result.SetFloat(val)
 		}
 	default:
-		return typ, errors.New(fmt.Sprintf("Unsupported numeric type %v", typ))
+		return result, unsupportedType(typ)
 	}
+	return result, nil
 }
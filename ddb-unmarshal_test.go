@@ -33,6 +33,113 @@ func TestDdbMarshaller_Unmarshal(t *testing.T) {
 			wantErr:  false,
 			wantData: prepareStruct(),
 		},
+		{
+			name: "nested structs",
+			args: args{
+				target: &testNested{},
+				source: prepareNestedDdb(),
+			},
+			wantErr: false,
+			wantData: func() *testNested {
+				data := prepareNestedStruct()
+				data.Home.Tags = map[string]string{}
+				return data
+			}(),
+		},
+		{
+			name: "lists",
+			args: args{
+				target: &testLists{},
+				source: prepareListsDdb(),
+			},
+			wantErr:  false,
+			wantData: prepareListsStruct(),
+		},
+		{
+			name: "pointers",
+			args: args{
+				target: &testPointers{},
+				source: func() map[string]*dynamodb.AttributeValue {
+					item := preparePointersDdb()
+					item["missing"] = &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+					return item
+				}(),
+			},
+			wantErr: false,
+			wantData: func() *testPointers {
+				data := preparePointersStruct()
+				*data.Expire = data.Expire.UTC()
+				return data
+			}(),
+		},
+		{
+			name: "integer widths and named types",
+			args: args{
+				target: &testKinds{},
+				source: prepareKindsDdb(),
+			},
+			wantErr:  false,
+			wantData: prepareKindsStruct(),
+		},
+		{
+			name: "maps with non-string keys",
+			args: args{
+				target: &testMaps{},
+				source: prepareMapsDdb(),
+			},
+			wantErr:  false,
+			wantData: prepareMapsStruct(),
+		},
+		{
+			name: "invalid integer map key",
+			args: args{
+				target: &testMaps{},
+				source: map[string]*dynamodb.AttributeValue{
+					"byByte": {M: map[string]*dynamodb.AttributeValue{"256": {B: []byte{1}}}},
+				},
+			},
+			wantErr:  true,
+			wantData: &testMaps{},
+		},
+		{
+			name: "overflow is rejected",
+			args: args{
+				target: &testKinds{},
+				source: map[string]*dynamodb.AttributeValue{
+					"i8": {N: aws.String("300")},
+				},
+			},
+			wantErr:  true,
+			wantData: &testKinds{},
+		},
+		{
+			name: "negative unsigned is rejected",
+			args: args{
+				target: &testKinds{},
+				source: map[string]*dynamodb.AttributeValue{
+					"u32": {N: aws.String("-1")},
+				},
+			},
+			wantErr:  true,
+			wantData: &testKinds{},
+		},
+		{
+			name: "nested required is required",
+			args: args{
+				target: &struct {
+					Inner testRequired `ddb:"inner"`
+				}{},
+				source: map[string]*dynamodb.AttributeValue{
+					"inner": {M: map[string]*dynamodb.AttributeValue{
+						"name": {S: aws.String("a Name")},
+					}},
+				},
+			},
+			wantErr: true,
+			wantData: &struct {
+				Inner testRequired `ddb:"inner"`
+			}{},
+		},
 		{
 			name: "required is required",
 			args: args{
@@ -1,9 +1,7 @@
 package ddbmarshal
 
 import (
-	"errors"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
-	"reflect"
 )
 
 func (me *DdbMarshaller) GetUnmarshaledFields(target interface{}, response map[string]*dynamodb.AttributeValue) (map[string]*dynamodb.AttributeValue, error) {
@@ -11,19 +9,13 @@ func (me *DdbMarshaller) GetUnmarshaledFields(target interface{}, response map[s
 	if err != nil {
 		return nil, err
 	}
-	fieldmap := make(map[string]*reflect.StructField)
-	for i, I := 0, targetValue.NumField(); i < I; i++ {
-		fieldType := targetValue.Type().Field(i)
-		if ddbSpec, ok := fieldType.Tag.Lookup(TagDdb); ok {
-			if !fieldType.IsExported() {
-				return nil, errors.New("can't use ddb field for unexported fieldType " + fieldType.Name)
-			}
-			if specs, err := ParseDdbTag(ddbSpec); err != nil {
-				return nil, err
-			} else {
-				fieldmap[specs.name] = &fieldType
-			}
-		}
+	fields, err := me.structFields(targetValue.Type(), me.addPrefixToTheFieldNames)
+	if err != nil {
+		return nil, err
+	}
+	fieldmap := make(map[string]*structField)
+	for i := range fields {
+		fieldmap[fields[i].spec.name] = &fields[i]
 	}
 
 	result := make(map[string]*dynamodb.AttributeValue)
@@ -0,0 +1,385 @@
+package ddbmarshal
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// validationRules are the "min=", "max=", "len=", "pattern=", "oneof=" and "nonempty" tag options:
+// min and max limit numbers or the length of strings, slices and maps, len is the exact length,
+// pattern is matched by strings, oneof lists `|`-separated allowed values of strings and numbers.
+// Only nonempty is checked for the absent values: attributes missing on unmarshal (or NULL) without default,
+// and fields not stored on marshal (nil pointers, empty fields with omitempty), the other rules apply to the present values
+type validationRules struct {
+	min      *float64
+	max      *float64
+	length   *int
+	pattern  *regexp.Regexp
+	oneOf    []string
+	nonEmpty bool
+}
+
+// parseRule reports false if the tag item is not a validation rule
+func (rules *validationRules) parseRule(key, value string) (bool, error) {
+	switch key {
+	case TagItemMin, TagItemMax:
+		limit, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return true, errors.New(fmt.Sprintf("invalid %q option: %v", key, err))
+		}
+		if key == TagItemMin {
+			rules.min = &limit
+		} else {
+			rules.max = &limit
+		}
+	case TagItemLen:
+		length, err := strconv.Atoi(value)
+		if err != nil || length < 0 {
+			return true, errors.New(fmt.Sprintf("invalid %q option %q", key, value))
+		}
+		rules.length = &length
+	case TagItemPattern:
+		pattern, err := regexp.Compile(value)
+		if err != nil {
+			return true, errors.New(fmt.Sprintf("invalid %q option: %v", key, err))
+		}
+		rules.pattern = pattern
+	case TagItemOneOf:
+		rules.oneOf = strings.Split(value, defaultSeparator)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// presence returns the rules checked for the absent value
+func (rules *validationRules) presence() *validationRules {
+	return &validationRules{nonEmpty: rules.nonEmpty}
+}
+
+// ValidationError is the violation of the validation rule, it matches ErrValidation
+type ValidationError struct {
+	Field     string // go field path, i.e. Address.Lines[2]
+	Attribute string // attribute path, i.e. address.lines[2]
+	Rule      string // violated rule, i.e. "min=1"
+	Message   string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("attribute %q (field %s) violates %s: %s", e.Attribute, e.Field, e.Rule, e.Message)
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// ValidationErrors lists all the violations: *ValidationError for the validation rules,
+// *UnmarshalError with ErrMissingRequired for the missing required attributes on unmarshal,
+// and *UnknownAttributesError in the strict mode; errors.Is and errors.As look into all of them
+type ValidationErrors []error
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e ValidationErrors) Is(target error) bool {
+	if target == ErrValidation {
+		return true
+	}
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e ValidationErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the validation rules of the struct fields, same as Marshal and Unmarshal do,
+// all the violations are reported as ValidationErrors
+func (me *DdbMarshaller) Validate(v interface{}) error {
+	value, err := getValidMarshallingTargetValue(v)
+	if err != nil {
+		return err
+	}
+	var violations ValidationErrors
+	if err := me.validateStruct(value, me.addPrefixToTheFieldNames, acceptAllFields, "", "", &violations); err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return violations
+	}
+	return nil
+}
+
+// validateStruct checks the rules of the fields accepted by the filter and of the nested values
+func (me *DdbMarshaller) validateStruct(value reflect.Value, prefix string, filter func(spec specs) bool, field, attribute string, violations *ValidationErrors) error {
+	if !me.hasRules(value.Type()) {
+		return nil
+	}
+	fields, err := me.structFields(value.Type(), prefix)
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		if f.spec.remain || !filter(f.spec) {
+			continue
+		}
+		fieldValue := fieldOrZero(value, f.index)
+		if f.defaultAttr != nil && me.marshalDefaults && fieldValue.IsZero() {
+			// the default is stored instead
+			continue
+		}
+		fieldPath, attributePath := joinPath(field, f.goName), joinPath(attribute, f.spec.name)
+		if rules := f.spec.rules; rules != nil {
+			if _, ok := fieldForRead(value, f.index); !ok || f.spec.omitEmpty && isEmptyValue(fieldValue) {
+				// the field is not stored
+				rules = rules.presence()
+			}
+			for _, violation := range checkRules(fieldValue, rules) {
+				violation.Field, violation.Attribute = fieldPath, attributePath
+				*violations = append(*violations, violation)
+			}
+		}
+		if err := me.validateValue(fieldValue, fieldPath, attributePath, violations); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldOrZero returns the field by index, or zero value if one of the embedded pointers on the path is nil
+func fieldOrZero(value reflect.Value, index []int) reflect.Value {
+	if fieldValue, ok := fieldForRead(value, index); ok {
+		return fieldValue
+	}
+	return reflect.Zero(value.Type().FieldByIndex(index).Type)
+}
+
+// checkRules adds the violations of the rules of the struct field, only nonempty is checked if the attribute is absent
+func (d *deferredErrors) checkRules(value reflect.Value, field structField, present bool) {
+	rules := field.spec.rules
+	if !present {
+		rules = rules.presence()
+	}
+	for _, violation := range checkRules(fieldOrZero(value, field.index), rules) {
+		violation.Field, violation.Attribute = field.goName, field.spec.name
+		d.violations = append(d.violations, violation)
+	}
+}
+
+// validateValue looks for the rules in the structs nested into the value
+func (me *DdbMarshaller) validateValue(value reflect.Value, field, attribute string, violations *ValidationErrors) error {
+	if !value.IsValid() || !me.hasRules(value.Type()) {
+		return nil
+	}
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return me.validateValue(value.Elem(), field, attribute, violations)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			index := fmt.Sprintf("[%d]", i)
+			if err := me.validateValue(value.Index(i), field+index, attribute+index, violations); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, key := range value.MapKeys() {
+			name := fmt.Sprint(key.Interface())
+			if err := me.validateValue(value.MapIndex(key), field+"["+name+"]", joinPath(attribute, name), violations); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		if inner, ok := unwrapOptional(value); ok {
+			return me.validateValue(inner, field, attribute, violations)
+		}
+		return me.validateStruct(value, "", acceptAllFields, field, attribute, violations)
+	}
+	return nil
+}
+
+// unwrapOptional returns the value of Optional, which is not valid for unset or null Optional
+func unwrapOptional(value reflect.Value) (reflect.Value, bool) {
+	if !value.Type().Implements(optionalSourceType) {
+		return reflect.Value{}, false
+	}
+	if state, inner := value.Interface().(optionalSource).optional(); state == optionalValue {
+		return inner, true
+	}
+	return reflect.Value{}, true
+}
+
+// hasRules reports whether the values of the type may have rules to check, it is cached per type
+func (me *DdbMarshaller) hasRules(typ reflect.Type) bool {
+	if result, ok := me.rulesCache.Load(typ); ok {
+		return result.(bool)
+	}
+	result := typeHasRules(typ, make(map[reflect.Type]bool))
+	me.rulesCache.Store(typ, result)
+	return result
+}
+
+// typeHasRules looks for the rules in the type and the types nested into it, visited types are skipped to stop on recursive types
+func typeHasRules(typ reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[typ] {
+		return false
+	}
+	visited[typ] = true
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return typeHasRules(typ.Elem(), visited)
+	case reflect.Interface:
+		return true
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			fieldType := typ.Field(i)
+			if ddbSpecStr, ok := fieldType.Tag.Lookup(TagDdb); ok {
+				if ddbSpecs, err := ParseDdbTag(ddbSpecStr); err != nil || ddbSpecs.rules != nil {
+					return true
+				}
+			}
+			if typeHasRules(fieldType.Type, visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ruleValueType returns the type the rules are applied to: pointers and Optional are unwrapped
+func ruleValueType(typ reflect.Type) reflect.Type {
+	for {
+		switch {
+		case typ.Kind() == reflect.Ptr:
+			typ = typ.Elem()
+		case typ.Kind() == reflect.Struct && typ.Implements(optionalSourceType):
+			typ = typ.Field(0).Type
+		default:
+			return typ
+		}
+	}
+}
+
+// compileRules checks the rules are applicable to the field type, so invalid rule is reported on the first use of the struct
+func compileRules(typ reflect.Type, rules *validationRules) error {
+	typ = ruleValueType(typ)
+	isNumber := isNumberType(typ)
+	hasLength := typ.Kind() == reflect.String || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array || typ.Kind() == reflect.Map
+	switch {
+	case (rules.min != nil || rules.max != nil) && !isNumber && !hasLength:
+		return errors.New(fmt.Sprintf("%q and %q options are not applicable to %v", TagItemMin, TagItemMax, typ))
+	case rules.length != nil && !hasLength:
+		return errors.New(fmt.Sprintf("%q option is not applicable to %v", TagItemLen, typ))
+	case rules.pattern != nil && typ.Kind() != reflect.String:
+		return errors.New(fmt.Sprintf("%q option is not applicable to %v", TagItemPattern, typ))
+	case rules.oneOf != nil && !isNumber && typ.Kind() != reflect.String:
+		return errors.New(fmt.Sprintf("%q option is not applicable to %v", TagItemOneOf, typ))
+	}
+	if isNumber {
+		for _, option := range rules.oneOf {
+			if _, err := parseStringToNumber(typ, option); err != nil {
+				return errors.New(fmt.Sprintf("invalid %q option %q for %v", TagItemOneOf, option, typ))
+			}
+		}
+	}
+	return nil
+}
+
+// checkRules returns the violations of the rules by the value, the rules other than nonempty are not checked for nil values
+func checkRules(value reflect.Value, rules *validationRules) []*ValidationError {
+	var result []*ValidationError
+	violation := func(rule string, format string, args ...interface{}) {
+		result = append(result, &ValidationError{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Struct {
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				value = reflect.Value{}
+				break
+			}
+			value = value.Elem()
+		} else if inner, ok := unwrapOptional(value); ok {
+			value = inner
+		} else {
+			break
+		}
+	}
+	if rules.nonEmpty && (!value.IsValid() || isEmptyValue(value)) {
+		violation(TagItemNonEmpty, "value is empty")
+	}
+	if !value.IsValid() {
+		return result
+	}
+	var measure float64
+	what := "value"
+	if rules.min != nil || rules.max != nil || rules.length != nil {
+		switch {
+		case isNumberType(value.Type()):
+			measure = numberValue(value)
+		case value.Kind() == reflect.String:
+			measure, what = float64(utf8.RuneCountInString(value.String())), "length"
+		default:
+			measure, what = float64(value.Len()), "length"
+		}
+	}
+	if rules.min != nil && measure < *rules.min {
+		violation(fmt.Sprintf("%s=%v", TagItemMin, *rules.min), "%s %v is less than %v", what, measure, *rules.min)
+	}
+	if rules.max != nil && measure > *rules.max {
+		violation(fmt.Sprintf("%s=%v", TagItemMax, *rules.max), "%s %v is greater than %v", what, measure, *rules.max)
+	}
+	if rules.length != nil && int(measure) != *rules.length {
+		violation(fmt.Sprintf("%s=%d", TagItemLen, *rules.length), "length %v is not %d", measure, *rules.length)
+	}
+	if rules.pattern != nil && !rules.pattern.MatchString(value.String()) {
+		violation(fmt.Sprintf("%s=%s", TagItemPattern, rules.pattern), "%q doesn't match", value.String())
+	}
+	if rules.oneOf != nil && !isOneOf(value, rules.oneOf) {
+		violation(fmt.Sprintf("%s=%s", TagItemOneOf, strings.Join(rules.oneOf, defaultSeparator)), "%v is not allowed", value.Interface())
+	}
+	return result
+}
+
+func numberValue(value reflect.Value) float64 {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(value.Uint())
+	}
+	return value.Float()
+}
+
+func isOneOf(value reflect.Value, options []string) bool {
+	for _, option := range options {
+		if value.Kind() == reflect.String {
+			if value.String() == option {
+				return true
+			}
+		} else if allowed, err := parseStringToNumber(value.Type(), option); err == nil && allowed.Interface() == value.Convert(allowed.Type()).Interface() {
+			return true
+		}
+	}
+	return false
+}
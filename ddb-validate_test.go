@@ -0,0 +1,268 @@
+package ddbmarshal
+
+import (
+	"errors"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type testValidLine struct {
+	Sku string `ddb:"sku,pattern='^[A-Z]{3}-[0-9]{1,4}$'"`
+	Qty int    `ddb:"qty,min=1,max=100"`
+}
+
+type testValidItem struct {
+	Id       string                   `ddb:"id,hash-key,len=4"`
+	Name     *string                  `ddb:"name,nonempty"`
+	Status   string                   `ddb:"status,oneof=new|paid"`
+	Priority int                      `ddb:"priority,oneof=1|2|3"`
+	Tags     []string                 `ddb:"tags,max=2"`
+	Lines    []testValidLine          `ddb:"lines,min=1"`
+	ByCode   map[string]testValidLine `ddb:"byCode"`
+	Note     Optional[string]         `ddb:"note,max=3"`
+}
+
+func prepareValidItem() *testValidItem {
+	name := "name"
+	return &testValidItem{
+		Id:       "id-1",
+		Name:     &name,
+		Status:   "new",
+		Priority: 2,
+		Tags:     []string{"a"},
+		Lines:    []testValidLine{{Sku: "ABC-1", Qty: 1}},
+		ByCode:   map[string]testValidLine{"x": {Sku: "XYZ-2", Qty: 100}},
+		Note:     Some("ok"),
+	}
+}
+
+func prepareInvalidItem() *testValidItem {
+	return &testValidItem{
+		Id:       "id",
+		Status:   "lost",
+		Priority: 5,
+		Tags:     []string{"a", "b", "c"},
+		Lines:    []testValidLine{{Sku: "ABC-1", Qty: 1}, {Sku: "ABC-12345", Qty: 0}},
+		ByCode:   map[string]testValidLine{"x": {Sku: "XYZ-2", Qty: 101}},
+		Note:     Some("long"),
+	}
+}
+
+// invalidItemViolations are the attribute paths and rules violated by prepareInvalidItem
+var invalidItemViolations = []string{
+	"byCode.x.qty max=100",
+	"id len=4",
+	"lines[1].qty min=1",
+	"lines[1].sku pattern=^[A-Z]{3}-[0-9]{1,4}$",
+	"name nonempty",
+	"note max=3",
+	"priority oneof=1|2|3",
+	"status oneof=new|paid",
+	"tags max=2",
+}
+
+func violationsOf(t *testing.T, err error) []string {
+	var violations ValidationErrors
+	if !errors.As(err, &violations) || !errors.Is(err, ErrValidation) {
+		t.Fatalf("error = %v, ValidationErrors expected", err)
+	}
+	var result []string
+	for _, violation := range violations {
+		var validationErr *ValidationError
+		if errors.As(violation, &validationErr) {
+			result = append(result, validationErr.Attribute+" "+validationErr.Rule)
+		} else {
+			result = append(result, violation.Error())
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestDdbMarshaller_Validate(t *testing.T) {
+	me := NewMarshaller()
+	if err := me.Validate(prepareValidItem()); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if got := violationsOf(t, me.Validate(prepareInvalidItem())); !reflect.DeepEqual(got, invalidItemViolations) {
+		t.Errorf("Validate() got = %v, want %v", got, invalidItemViolations)
+	}
+}
+
+func TestDdbMarshaller_MarshalValidation(t *testing.T) {
+	me := NewMarshaller()
+	if _, err := me.Marshal(prepareValidItem()); err != nil {
+		t.Errorf("Marshal() error = %v", err)
+	}
+	if got := violationsOf(t, errorOf(me.Marshal(prepareInvalidItem()))); !reflect.DeepEqual(got, invalidItemViolations) {
+		t.Errorf("Marshal() got = %v, want %v", got, invalidItemViolations)
+	}
+	item := prepareInvalidItem()
+	item.Id = "id-1"
+	if _, err := me.MarshalTagFilter(item, IsKeyField); err != nil {
+		t.Errorf("MarshalTagFilter() error = %v, only key fields are expected to be validated", err)
+	}
+}
+
+func errorOf(_ map[string]*dynamodb.AttributeValue, err error) error {
+	return err
+}
+
+func TestDdbMarshaller_UnmarshalValidation(t *testing.T) {
+	me := NewMarshaller()
+	valid, err := me.Marshal(prepareValidItem())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var data testValidItem
+	if err := me.Unmarshal(&data, valid); err != nil {
+		t.Errorf("Unmarshal() error = %v", err)
+	}
+	item := map[string]*dynamodb.AttributeValue{
+		"id":       {S: aws.String("id")},
+		"status":   {S: aws.String("lost")},
+		"priority": {N: aws.String("5")},
+		"tags":     {SS: aws.StringSlice([]string{"a", "b", "c"})},
+		"lines": {L: []*dynamodb.AttributeValue{
+			{M: map[string]*dynamodb.AttributeValue{"sku": {S: aws.String("ABC-1")}, "qty": {N: aws.String("1")}}},
+			{M: map[string]*dynamodb.AttributeValue{"sku": {S: aws.String("abc")}, "qty": {N: aws.String("0")}}},
+		}},
+		"byCode": {M: map[string]*dynamodb.AttributeValue{
+			"x": {M: map[string]*dynamodb.AttributeValue{"sku": {S: aws.String("XYZ-2")}, "qty": {N: aws.String("101")}}},
+		}},
+		"note": {S: aws.String("long")},
+	}
+	if got := violationsOf(t, me.Unmarshal(&testValidItem{}, item)); !reflect.DeepEqual(got, invalidItemViolations) {
+		t.Errorf("Unmarshal() got = %v, want %v", got, invalidItemViolations)
+	}
+}
+
+func TestDdbMarshaller_UnmarshalRequiredAggregated(t *testing.T) {
+	type inner struct {
+		A string `ddb:"a,required"`
+		B string `ddb:"b,required"`
+	}
+	type outer struct {
+		Id    string  `ddb:"id,required"`
+		Inner []inner `ddb:"inner"`
+		Name  string  `ddb:"name,nonempty"`
+	}
+	me := NewMarshaller()
+	err := me.Unmarshal(&outer{}, map[string]*dynamodb.AttributeValue{
+		"id":    {S: aws.String("x")},
+		"inner": {L: []*dynamodb.AttributeValue{{M: map[string]*dynamodb.AttributeValue{}}}},
+	})
+	if !errors.Is(err, ErrMissingRequired) {
+		t.Fatalf("Unmarshal() error = %v, want %v", err, ErrMissingRequired)
+	}
+	want := []string{
+		`can't unmarshal attribute "inner[0].a" into field Inner[0].A: missing required attribute`,
+		`can't unmarshal attribute "inner[0].b" into field Inner[0].B: missing required attribute`,
+		"name nonempty",
+	}
+	if got := violationsOf(t, err); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmarshal() got = %v, want %v", got, want)
+	}
+}
+
+type testAbsentRules struct {
+	Age  int    `ddb:"age,min=18"`
+	Code string `ddb:"code,omitempty,len=2"`
+	Kind string `ddb:"kind,oneof=a|b"`
+	Name string `ddb:"name,nonempty,max=5"`
+}
+
+func TestDdbMarshaller_UnmarshalAbsentRules(t *testing.T) {
+	tests := []struct {
+		name string
+		item map[string]*dynamodb.AttributeValue
+		want []string
+	}{
+		{
+			name: "absent attributes",
+			item: map[string]*dynamodb.AttributeValue{"name": {S: aws.String("bob")}},
+		},
+		{
+			name: "null attributes",
+			item: map[string]*dynamodb.AttributeValue{
+				"age":  {NULL: aws.Bool(true)},
+				"kind": {NULL: aws.Bool(true)},
+				"name": {S: aws.String("bob")},
+			},
+		},
+		{
+			name: "nonempty of absent attribute",
+			item: map[string]*dynamodb.AttributeValue{},
+			want: []string{"name nonempty"},
+		},
+		{
+			name: "present attributes",
+			item: map[string]*dynamodb.AttributeValue{
+				"age":  {N: aws.String("10")},
+				"code": {S: aws.String("")},
+				"kind": {S: aws.String("c")},
+				"name": {S: aws.String("robert")},
+			},
+			want: []string{"age min=18", "code len=2", "kind oneof=a|b", "name max=5"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewMarshaller().Unmarshal(&testAbsentRules{}, tt.item)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("Unmarshal() error = %v", err)
+				}
+				return
+			}
+			if got := violationsOf(t, err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDdbMarshaller_MarshalAbsentRules(t *testing.T) {
+	me := NewMarshaller()
+	if _, err := me.Marshal(&testAbsentRules{Age: 18, Kind: "a", Name: "bob"}); err != nil {
+		t.Errorf("Marshal() error = %v, empty omitempty field is not expected to be validated", err)
+	}
+	want := []string{"age min=18", "kind oneof=a|b", "name nonempty"}
+	if got := violationsOf(t, errorOf(me.Marshal(&testAbsentRules{}))); !reflect.DeepEqual(got, want) {
+		t.Errorf("Marshal() got = %v, want %v", got, want)
+	}
+}
+
+func TestDdbMarshaller_InvalidRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		target interface{}
+	}{
+		{"pattern of number", &struct {
+			V int `ddb:"v,pattern=^1$"`
+		}{}},
+		{"min of bool", &struct {
+			V bool `ddb:"v,min=1"`
+		}{}},
+		{"len of number", &struct {
+			V int `ddb:"v,len=1"`
+		}{}},
+		{"oneof of not a number", &struct {
+			V int `ddb:"v,oneof=1|x"`
+		}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			me := NewMarshaller()
+			if err := me.Validate(tt.target); err == nil {
+				t.Errorf("Validate() error expected")
+			}
+			if err := me.Unmarshal(tt.target, map[string]*dynamodb.AttributeValue{}); err == nil {
+				t.Errorf("Unmarshal() error expected")
+			}
+		})
+	}
+}
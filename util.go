@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"time"
 )
 
 func getValidMarshallingTargetValue(target interface{}) (val reflect.Value, err error) {
@@ -22,3 +23,59 @@ func getValidMarshallingTargetValue(target interface{}) (val reflect.Value, err
 	}
 	return targetValue, nil
 }
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// isNumberType reports types stored as N: integers and floats of any width (including named ones)
+func isNumberType(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// isScalarType reports types natively stored as N, S or BOOL
+func isScalarType(typ reflect.Type) bool {
+	return isNumberType(typ) || typ == timeType || typ.Kind() == reflect.String || typ.Kind() == reflect.Bool
+}
+
+// isBytesType reports byte slices and fixed-size byte arrays
+func isBytesType(typ reflect.Type) bool {
+	return (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) && typ.Elem().Kind() == reflect.Uint8
+}
+
+// isEmptyValue reports values skipped by omitempty: false, 0, "", empty slices and maps, nil pointers and zero time
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return value.Len() == 0
+	case reflect.Bool:
+		return !value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return value.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return value.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return value.IsNil()
+	case reflect.Struct:
+		if value.Type() == timeType {
+			return value.Interface().(time.Time).IsZero()
+		}
+	}
+	return false
+}
+
+// addressOf returns pointer to the value, the value is copied if its address can't be taken
+func addressOf(value reflect.Value) reflect.Value {
+	if value.CanAddr() {
+		return value.Addr()
+	}
+	ptr := reflect.New(value.Type())
+	ptr.Elem().Set(value)
+	return ptr
+}
@@ -3,6 +3,7 @@ package ddbmarshal
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func Test_getValidMarshallingTargetValue(t *testing.T) {
@@ -57,3 +58,34 @@ func Test_getValidMarshallingTargetValue(t *testing.T) {
 		})
 	}
 }
+
+func Test_isEmptyValue(t *testing.T) {
+	var nilPtr *string
+	tests := []struct {
+		name  string
+		value interface{}
+		want  bool
+	}{
+		{"empty string", "", true},
+		{"string", "x", false},
+		{"zero int", int32(0), true},
+		{"int", int8(-1), false},
+		{"zero uint", uint(0), true},
+		{"zero float", 0.0, true},
+		{"false", false, true},
+		{"empty slice", []string{}, true},
+		{"slice", []int{0}, false},
+		{"empty map", map[string]int{}, true},
+		{"nil pointer", nilPtr, true},
+		{"zero time", time.Time{}, true},
+		{"time", time.Unix(1, 0), false},
+		{"struct", struct{ A int }{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isEmptyValue(reflect.ValueOf(tt.value)); got != tt.want {
+				t.Errorf("isEmptyValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}